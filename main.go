@@ -3,9 +3,15 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
 
 	"what-to-eat/pkg/api"
+	"what-to-eat/pkg/experiment"
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/menustore"
+	"what-to-eat/pkg/replay"
 	"what-to-eat/pkg/vertex"
 
 	"github.com/go-chi/chi/v5"
@@ -14,16 +20,78 @@ import (
 )
 
 func main() {
+	// Persist menus on disk when a store directory is configured
+	if dir := os.Getenv("MENU_STORE_DIR"); dir != "" {
+		store, err := menustore.NewFileStore(dir)
+		if err != nil {
+			log.Fatalf("Failed to open menu store: %v", err)
+		}
+		menustore.Default = store
+	}
+
+	// Load experiments and keep their outcomes on disk when configured
+	if path := os.Getenv("EXPERIMENTS_FILE"); path != "" {
+		registry, err := experiment.LoadRegistry(path)
+		if err != nil {
+			log.Fatalf("Failed to load experiments: %v", err)
+		}
+		experiment.Default = registry
+	}
+	if dir := os.Getenv("EXPERIMENT_STORE_DIR"); dir != "" {
+		store, err := experiment.NewFileStore(dir)
+		if err != nil {
+			log.Fatalf("Failed to open experiment store: %v", err)
+		}
+		experiment.DefaultStore = store
+	}
+
+	// Record or replay upstream traffic when configured
+	transport, err := replay.New(replay.Mode(os.Getenv("UPSTREAM_MODE")), os.Getenv("UPSTREAM_FIXTURE_DIR"), nil)
+	if err != nil {
+		log.Fatalf("Failed to configure upstream transport: %v", err)
+	}
+	if transport.Mode() != replay.ModeOff {
+		config := foodpanda.DefaultConfig()
+		config.Transport = transport
+		foodpanda.DefaultClient = foodpanda.NewClient(config)
+		vertex.SetTransport(transport)
+		if transport.Mode() == replay.ModeReplay {
+			vertex.StaticAccessToken = "replay"
+		}
+		log.Printf("Upstream traffic mode: %s", transport.Mode())
+	}
+
+	// Select where the suggestion flow gets menus from
+	switch provider := os.Getenv("MENU_PROVIDER"); provider {
+	case "", "puppeteer":
+		vertex.Menus = vertex.NewPuppeteerMenuProvider(vertex.PuppeteerConfig{
+			URL:        os.Getenv("PUPPETEER_URL"),
+			AuthHeader: os.Getenv("PUPPETEER_AUTH"),
+			Transport:  transport,
+		})
+	case "native":
+		vertex.Menus = vertex.NewNativeMenuProvider(foodpanda.DefaultClient, 0)
+	case "fixture":
+		fixtures, err := vertex.LoadFixtureMenuProvider(os.Getenv("MENU_FIXTURE_DIR"))
+		if err != nil {
+			log.Fatalf("Failed to load menu fixtures: %v", err)
+		}
+		vertex.Menus = fixtures
+	default:
+		log.Fatalf("Unknown MENU_PROVIDER: %s", provider)
+	}
+
 	r := chi.NewRouter()
 
 	// Middleware
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5173"},
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Client-Id"},
+		ExposedHeaders:   []string{"Link", "X-Request-Id", "X-Cache", "X-Experiment", "X-Matcher"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -31,13 +99,23 @@ func main() {
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		type Response struct {
-			Message string `json:"message"`
-			Status  int    `json:"status"`
+			Message   string            `json:"message"`
+			Status    int               `json:"status"`
+			Upstreams map[string]string `json:"upstreams"`
 		}
 
 		response := Response{
 			Message: "Service is healthy",
 			Status:  http.StatusOK,
+			Upstreams: map[string]string{
+				"foodpanda":      foodpanda.DefaultClient.BreakerState(),
+				"foodpanda_menu": foodpanda.DefaultClient.MenuBreakerState(),
+			},
+		}
+		for _, state := range response.Upstreams {
+			if state != foodpanda.BreakerClosed {
+				response.Message = "Service is degraded"
+			}
 		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
@@ -48,6 +126,9 @@ func main() {
 		// Restaurant picker
 		r.Route("/picker", func(r chi.Router) {
 			r.Get("/random", api.RandomRestaurantHandler)
+			r.Get("/shortlist", api.ShortlistHandler)
+			r.Get("/spin", api.SpinHandler)
+			r.Post("/group", api.GroupPickHandler)
 			r.Route("/ai", func(r chi.Router) {
 				r.Post("/filter-categories", vertex.FilteredCategories)
 				r.Post("/suggestion", vertex.RestaurantSuggestion)
@@ -57,10 +138,30 @@ func main() {
 		// Restaurant routes
 		r.Route("/restaurants", func(r chi.Router) {
 			r.Get("/menu", api.GetMenuHandler)
+			r.With(api.RequireAdminToken(os.Getenv("ADMIN_TOKEN"))).Delete("/menu", api.InvalidateMenuHandler)
+		})
+
+		// Lunch poll rooms
+		r.Route("/polls", func(r chi.Router) {
+			r.Post("/", api.CreatePollHandler)
+			r.Route("/{roomID}", func(r chi.Router) {
+				r.Get("/", api.GetPollHandler)
+				r.Post("/candidates", api.AddPollCandidateHandler)
+				r.Post("/votes", api.VotePollHandler)
+				r.Get("/events", api.PollEventsHandler)
+			})
+		})
+
+		// Ranking and prompt experiments
+		r.Route("/experiments", func(r chi.Router) {
+			r.Get("/", api.ListExperimentsHandler)
+			r.Post("/{name}/outcomes", api.RecordOutcomeHandler)
+			r.Get("/{name}/summary", api.ExperimentSummaryHandler)
 		})
 
 		// Cuisines route
 		r.Get("/cuisines", api.GetCuisinesHandler)
+		r.Get("/cuisines/stats", api.CuisineStatsHandler)
 	})
 
 	fmt.Println("Server running on port 3000")
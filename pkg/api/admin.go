@@ -0,0 +1,24 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"what-to-eat/pkg/apierror"
+)
+
+// RequireAdminToken guards operator-only routes behind a bearer token. With
+// no token configured the routes are closed to everyone.
+func RequireAdminToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing or invalid admin token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
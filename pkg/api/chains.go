@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+
+	"what-to-eat/pkg/structure"
+)
+
+// Strategies for collapsing chain branches
+const (
+	ChainDedupeNearest = "nearest"
+	ChainDedupeRating  = "rating"
+)
+
+// parseChainDedupe validates the dedupeChains query parameter; an empty
+// value disables de-duplication
+func parseChainDedupe(value string) (string, error) {
+	switch value {
+	case "", ChainDedupeNearest, ChainDedupeRating:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown dedupeChains value: %s", value)
+	}
+}
+
+// dedupeChains keeps one branch per chain, chosen by strategy, and returns
+// the number of branches that were dropped. Restaurants without a chain are
+// kept as they are.
+func dedupeChains(candidates []structure.Restaurant, strategy string) ([]structure.Restaurant, int) {
+	if strategy == "" {
+		return candidates, 0
+	}
+
+	better := func(a, b structure.Restaurant) bool {
+		if strategy == ChainDedupeRating {
+			if a.Rating != b.Rating {
+				return a.Rating > b.Rating
+			}
+			return a.ReviewNumber > b.ReviewNumber
+		}
+		return a.Distance < b.Distance
+	}
+
+	// Remember where each chain's representative sits so the original order
+	// is preserved
+	chainIndex := make(map[string]int)
+	var deduped []structure.Restaurant
+	merged := 0
+
+	for _, candidate := range candidates {
+		code := candidate.Chain.Code
+		if code == "" {
+			deduped = append(deduped, candidate)
+			continue
+		}
+
+		i, seen := chainIndex[code]
+		if !seen {
+			candidate.Branches = 1
+			chainIndex[code] = len(deduped)
+			deduped = append(deduped, candidate)
+			continue
+		}
+
+		merged++
+		branches := deduped[i].Branches + 1
+		if better(candidate, deduped[i]) {
+			deduped[i] = candidate
+		}
+		deduped[i].Branches = branches
+	}
+
+	return deduped, merged
+}
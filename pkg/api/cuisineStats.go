@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/cuisine"
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/structure"
+)
+
+// cuisineAccumulator collects the values one cuisine's stats are computed from
+type cuisineAccumulator struct {
+	title     string
+	count     int
+	ratings   []float64
+	fees      []float64
+	times     []float64
+	discounts int
+	nearest   *structure.RestaurantItem
+}
+
+// CuisineStatsHandler summarises every cuisine in the full listing around a
+// location. Temporarily closed vendors are left out, and a vendor counts
+// once for each cuisine it lists. Listings longer than the client's item cap
+// are cut short, which the response flags as truncated.
+func CuisineStatsHandler(w http.ResponseWriter, r *http.Request) {
+	latitude, longitude, err := parseCoordinates(r.URL.Query())
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// Resolve the market from explicit params or the coordinates
+	market, err := foodpanda.ResolveMarket(r.URL.Query().Get("country"), r.URL.Query().Get("language"), latitude, longitude)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid market: "+err.Error())
+		return
+	}
+
+	foodpandaResp, cacheStatus, err := foodpanda.DefaultClient.CachedListAllVendors(r.Context(), foodpanda.ListingOptions{
+		Latitude:   latitude,
+		Longitude:  longitude,
+		Country:    market.Country,
+		LanguageID: market.LanguageID,
+	}, 0)
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to fetch data", err)
+		return
+	}
+
+	language := cuisine.Negotiate(r, market.Language)
+	accumulators := make(map[int]*cuisineAccumulator)
+	restaurants := 0
+
+	for i := range foodpandaResp.Data.Items {
+		item := &foodpandaResp.Data.Items[i]
+		if item.Metadata.IsTemporaryClosed {
+			continue
+		}
+		restaurants++
+
+		for _, c := range item.Cuisines {
+			acc, ok := accumulators[c.ID]
+			if !ok {
+				acc = &cuisineAccumulator{title: cuisine.Label(c.ID, language, c.Name)}
+				accumulators[c.ID] = acc
+			}
+
+			acc.count++
+			if item.ReviewNumber > 0 {
+				acc.ratings = append(acc.ratings, item.Rating)
+			}
+			if item.Metadata.IsDeliveryAvailable {
+				acc.fees = append(acc.fees, item.MinimumDeliveryFee)
+				acc.times = append(acc.times, item.MinimumDeliveryTime)
+			}
+			if item.Metadata.HasDiscount {
+				acc.discounts++
+			}
+			if acc.nearest == nil || item.Distance < acc.nearest.Distance {
+				acc.nearest = item
+			}
+		}
+	}
+
+	stats := make([]structure.CuisineStats, 0, len(accumulators))
+	for id, acc := range accumulators {
+		nearest := toRestaurant(*acc.nearest, language)
+		stats = append(stats, structure.CuisineStats{
+			ID:                 id,
+			Title:              acc.title,
+			Count:              acc.count,
+			RatedCount:         len(acc.ratings),
+			MedianRating:       median(acc.ratings),
+			MedianDeliveryFee:  median(acc.fees),
+			MedianDeliveryTime: median(acc.times),
+			DiscountShare:      float64(acc.discounts) / float64(acc.count),
+			Nearest:            &nearest,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].ID < stats[j].ID
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", language)
+	w.Header().Set("Vary", "Accept-Language")
+	w.Header().Set("X-Cache", cacheStatus)
+	json.NewEncoder(w).Encode(structure.CuisineStatsResponse{
+		Cuisines:    stats,
+		Restaurants: restaurants,
+		Available:   foodpandaResp.Data.AvailableCount,
+		Returned:    len(foodpandaResp.Data.Items),
+		Truncated:   len(foodpandaResp.Data.Items) < foodpandaResp.Data.AvailableCount,
+		Market:      market,
+		Cache:       cacheStatus,
+		Language:    language,
+	})
+}
+
+// median returns the middle value of values, or zero for none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/structure"
+)
+
+func cuisineStats(t *testing.T) structure.CuisineStatsResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	CuisineStatsHandler(w, httptest.NewRequest("GET", "/api/v1/cuisines/stats?"+taipei101, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	var resp structure.CuisineStatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestCuisineStatsReplayIsComplete(t *testing.T) {
+	useReplayClient(t)
+
+	resp := cuisineStats(t)
+	if resp.Available != 6 || resp.Returned != 6 || resp.Truncated {
+		t.Errorf("available = %d, returned = %d, truncated = %v; want the whole listing of 6",
+			resp.Available, resp.Returned, resp.Truncated)
+	}
+	// The temporarily closed vendor is left out of the stats
+	if resp.Restaurants != 5 {
+		t.Errorf("restaurants = %d, want 5", resp.Restaurants)
+	}
+}
+
+func TestCuisineStatsFlagsTruncatedListings(t *testing.T) {
+	raw, err := os.ReadFile("testdata/replay/disco_deliveryhero_io-5a183bdc5a00730f.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixture struct {
+		Response struct {
+			Body structure.FoodPandaRestaurantResponse `json:"body"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		t.Fatal(err)
+	}
+	listing := fixture.Response.Body
+	listing.Data.AvailableCount = 2500
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(listing)
+	}))
+	defer server.Close()
+
+	config := foodpanda.DefaultConfig()
+	config.BaseURL = server.URL
+	config.MaxListingItems = 4
+	previous := foodpanda.DefaultClient
+	foodpanda.DefaultClient = foodpanda.NewClient(config)
+	defer func() { foodpanda.DefaultClient = previous }()
+
+	resp := cuisineStats(t)
+	if resp.Available != 2500 || resp.Returned != 4 || !resp.Truncated {
+		t.Errorf("available = %d, returned = %d, truncated = %v; want 2500, 4 and truncated",
+			resp.Available, resp.Returned, resp.Truncated)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/experiment"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// OutcomeRequestBody is the body of POST /experiments/{name}/outcomes
+type OutcomeRequestBody struct {
+	ClientID       string `json:"clientId"`
+	Event          string `json:"event"`
+	RestaurantCode string `json:"restaurantCode"`
+}
+
+// ListExperimentsHandler returns the configured experiments
+func ListExperimentsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(experiment.Default.List())
+}
+
+// RecordOutcomeHandler records whether a client accepted or rerolled what
+// they were shown. The variant is recomputed from the client ID, so clients
+// can't report against an arm they weren't in.
+func RecordOutcomeHandler(w http.ResponseWriter, r *http.Request) {
+	exp, ok := experiment.Default.Get(chi.URLParam(r, "name"))
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "Unknown experiment")
+		return
+	}
+
+	var body OutcomeRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if body.ClientID == "" {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "clientId is required")
+		return
+	}
+	if body.Event != experiment.EventAccepted && body.Event != experiment.EventRerolled {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "event must be accepted or rerolled")
+		return
+	}
+
+	outcome := experiment.Outcome{
+		Experiment:     exp.Name,
+		Variant:        exp.Assign(body.ClientID).Name,
+		ClientID:       body.ClientID,
+		Event:          body.Event,
+		RestaurantCode: body.RestaurantCode,
+		Time:           time.Now(),
+	}
+	if err := experiment.DefaultStore.Record(outcome); err != nil {
+		apierror.WriteError(w, r, "Failed to record outcome", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(outcome)
+}
+
+// ExperimentSummaryHandler returns per-variant outcome counts and rates
+func ExperimentSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	exp, ok := experiment.Default.Get(chi.URLParam(r, "name"))
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "Unknown experiment")
+		return
+	}
+
+	outcomes, err := experiment.DefaultStore.Outcomes(exp.Name)
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to read outcomes", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(experiment.Summarize(exp, outcomes))
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"what-to-eat/pkg/structure"
+)
+
+// Fulfillment modes for the random picker
+const (
+	FulfillmentDelivery = "delivery"
+	FulfillmentPickup   = "pickup"
+	FulfillmentEither   = "either"
+)
+
+// parseFulfillment validates the fulfillment query parameter
+func parseFulfillment(value string) (string, error) {
+	switch value {
+	case "":
+		return FulfillmentDelivery, nil
+	case FulfillmentDelivery, FulfillmentPickup, FulfillmentEither:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown fulfillment: %s", value)
+	}
+}
+
+// fulfillable reports whether item supports the fulfillment mode
+func fulfillable(item structure.RestaurantItem, fulfillment string) bool {
+	switch fulfillment {
+	case FulfillmentPickup:
+		return item.Metadata.IsPickupAvailable
+	case FulfillmentEither:
+		return item.Metadata.IsDeliveryAvailable || item.Metadata.IsPickupAvailable
+	default:
+		return item.Metadata.IsDeliveryAvailable
+	}
+}
+
+// restaurantFilter holds the optional picker filters. Nil maximums and zero
+// minimums are not applied.
+type restaurantFilter struct {
+	MaxDistance     *float64
+	MinRating       float64
+	MinReviews      int
+	PriceLevels     map[int]bool
+	MaxDeliveryFee  *float64
+	MaxDeliveryTime *float64
+	MaxMinimumOrder *float64
+	DiscountOnly    bool
+}
+
+// parseRestaurantFilter reads the filter query parameters
+func parseRestaurantFilter(q url.Values) (restaurantFilter, error) {
+	var f restaurantFilter
+	var err error
+
+	if f.MaxDistance, err = parseOptionalFloat(q, "maxDistance"); err != nil {
+		return f, err
+	}
+	if f.MaxDeliveryFee, err = parseOptionalFloat(q, "maxDeliveryFee"); err != nil {
+		return f, err
+	}
+	if f.MaxDeliveryTime, err = parseOptionalFloat(q, "maxDeliveryTime"); err != nil {
+		return f, err
+	}
+	if f.MaxMinimumOrder, err = parseOptionalFloat(q, "maxMinimumOrder"); err != nil {
+		return f, err
+	}
+
+	if minRating, err := parseOptionalFloat(q, "minRating"); err != nil {
+		return f, err
+	} else if minRating != nil {
+		f.MinRating = *minRating
+	}
+
+	if value := q.Get("minReviews"); value != "" {
+		f.MinReviews, err = strconv.Atoi(value)
+		if err != nil || f.MinReviews < 0 {
+			return f, fmt.Errorf("invalid minReviews value: %s", value)
+		}
+	}
+
+	// Price levels are Foodpanda budget values, e.g. priceLevel=1,2
+	if value := q.Get("priceLevel"); value != "" {
+		f.PriceLevels = make(map[int]bool)
+		for _, level := range strings.Split(value, ",") {
+			budget, err := strconv.Atoi(strings.TrimSpace(level))
+			if err != nil || budget < 1 {
+				return f, fmt.Errorf("invalid priceLevel value: %s", level)
+			}
+			f.PriceLevels[budget] = true
+		}
+	}
+
+	if value := q.Get("discountOnly"); value != "" {
+		f.DiscountOnly, err = strconv.ParseBool(value)
+		if err != nil {
+			return f, fmt.Errorf("invalid discountOnly value: %s", value)
+		}
+	}
+
+	return f, nil
+}
+
+// Policies for restaurants the client was recently given
+const (
+	RepeatSkip       = "skip"
+	RepeatDownweight = "downweight"
+)
+
+// downweightFactor scales the weight of recently picked restaurants. Only
+// weighted and top-k draws look at weights.
+const downweightFactor = 0.25
+
+// exclusionOptions describes restaurants the picker should avoid
+type exclusionOptions struct {
+	Vendors      map[string]bool
+	Chains       map[string]bool
+	RecentWindow time.Duration
+	RepeatPolicy string
+}
+
+// defaultRecentWindow applies when repeatPolicy is given without
+// avoidRecentDays
+const defaultRecentWindow = 2 * 24 * time.Hour
+
+// parseExclusionOptions reads the exclusion and repeat-avoidance parameters.
+// excludeVendors accepts vendor IDs or codes. Repeat avoidance is opt-in:
+// identifying the client alone doesn't turn it on.
+func parseExclusionOptions(q url.Values) (exclusionOptions, error) {
+	opts := exclusionOptions{
+		Vendors:      splitSet(q.Get("excludeVendors")),
+		Chains:       splitSet(q.Get("excludeChains")),
+		RepeatPolicy: q.Get("repeatPolicy"),
+	}
+	if opts.RepeatPolicy != "" {
+		opts.RecentWindow = defaultRecentWindow
+	}
+
+	if value := q.Get("avoidRecentDays"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 || days > 30 {
+			return opts, fmt.Errorf("invalid avoidRecentDays value: %s", value)
+		}
+		opts.RecentWindow = time.Duration(days) * 24 * time.Hour
+	}
+
+	switch opts.RepeatPolicy {
+	case "":
+		opts.RepeatPolicy = RepeatSkip
+	case RepeatSkip, RepeatDownweight:
+	default:
+		return opts, fmt.Errorf("unknown repeatPolicy: %s", opts.RepeatPolicy)
+	}
+
+	return opts, nil
+}
+
+// excluded reports whether item was explicitly excluded
+func (o exclusionOptions) excluded(item structure.RestaurantItem) bool {
+	return o.Vendors[item.Code] || o.Vendors[strconv.Itoa(item.ID)] ||
+		(item.Chain.Code != "" && o.Chains[item.Chain.Code])
+}
+
+// splitSet turns a comma separated list into a set
+func splitSet(value string) map[string]bool {
+	set := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			set[part] = true
+		}
+	}
+	return set
+}
+
+func parseOptionalFloat(q url.Values, key string) (*float64, error) {
+	value := q.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 {
+		return nil, fmt.Errorf("invalid %s value: %s", key, value)
+	}
+	return &parsed, nil
+}
+
+// matches reports whether item passes every filter
+func (f restaurantFilter) matches(item structure.RestaurantItem) bool {
+	if f.MaxDistance != nil && item.Distance > *f.MaxDistance {
+		return false
+	}
+	if item.Rating < f.MinRating {
+		return false
+	}
+	if item.ReviewNumber < f.MinReviews {
+		return false
+	}
+	if f.PriceLevels != nil && !f.PriceLevels[item.Budget] {
+		return false
+	}
+	if f.MaxDeliveryFee != nil && item.MinimumDeliveryFee > *f.MaxDeliveryFee {
+		return false
+	}
+	if f.MaxDeliveryTime != nil && item.MinimumDeliveryTime > *f.MaxDeliveryTime {
+		return false
+	}
+	if f.MaxMinimumOrder != nil && item.MinimumOrderAmount > *f.MaxMinimumOrder {
+		return false
+	}
+	if f.DiscountOnly && !item.Metadata.HasDiscount {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseExclusionOptionsRepeatAvoidanceIsOptIn(t *testing.T) {
+	tests := []struct {
+		query string
+		want  time.Duration
+	}{
+		{"clientId=abc", 0},
+		{"clientId=abc&repeatPolicy=skip", 2 * 24 * time.Hour},
+		{"clientId=abc&avoidRecentDays=5", 5 * 24 * time.Hour},
+		{"repeatPolicy=downweight&avoidRecentDays=0", 0},
+	}
+
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		opts, err := parseExclusionOptions(q)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if opts.RecentWindow != tt.want {
+			t.Errorf("%s: RecentWindow = %v, want %v", tt.query, opts.RecentWindow, tt.want)
+		}
+	}
+}
+
+func TestParsePickerRequestReadsClientIDHeader(t *testing.T) {
+	r := httptest.NewRequest("GET", "/random?latitude=25.04&longitude=121.56", nil)
+	r.Header.Set("X-Client-Id", "header-client")
+
+	req, err := parsePickerRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ClientID != "header-client" {
+		t.Errorf("ClientID = %q, want header-client", req.ClientID)
+	}
+	if req.Exclusions.RecentWindow != 0 {
+		t.Errorf("RecentWindow = %v, want repeat avoidance off", req.Exclusions.RecentWindow)
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"math"
+	"sort"
+
+	"what-to-eat/pkg/structure"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	// walkingSpeedKmh is a typical adult walking pace
+	walkingSpeedKmh = 5.0
+)
+
+// haversineKm returns the great-circle distance between two points in km
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// walkingMinutes estimates the time to walk distanceKm
+func walkingMinutes(distanceKm float64) float64 {
+	return math.Round(distanceKm / walkingSpeedKmh * 60)
+}
+
+// sortItemsByCode orders listing items by vendor code
+func sortItemsByCode(items []structure.RestaurantItem) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Code < items[j].Code
+	})
+}
+
+// sortRestaurantsByCode orders candidates by vendor code
+func sortRestaurantsByCode(restaurants []structure.Restaurant) {
+	sort.Slice(restaurants, func(i, j int) bool {
+		return restaurants[i].Code < restaurants[j].Code
+	})
+}
@@ -1,7 +1,171 @@
 package api
 
-import "net/http"
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
 
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/menustore"
+	"what-to-eat/pkg/structure"
+)
+
+type SimplifiedMenu struct {
+	Code   string           `json:"code"`
+	Name   string           `json:"name"`
+	Menu   []MenuCategory   `json:"menu"`
+	Market structure.Market `json:"market"`
+}
+
+type MenuCategory struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Products    []Product `json:"products"`
+}
+
+type Product struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       float64     `json:"price"`
+	ImageURL    string      `json:"image_url,omitempty"`
+	Variations  []Variation `json:"variations,omitempty"`
+}
+
+type Variation struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// transformResponse reduces a full vendor menu to the fields the frontend shows
+func transformResponse(fullResponse *structure.FoodPandaMenuResponse) SimplifiedMenu {
+	// Create simplified menu structure
+	simplified := SimplifiedMenu{
+		Code: fullResponse.Data.Code,
+		Name: fullResponse.Data.Name,
+	}
+
+	// Process only the first menu (usually the main menu)
+	if len(fullResponse.Data.Menus) > 0 {
+		for _, category := range fullResponse.Data.Menus[0].MenuCategories {
+			menuCat := MenuCategory{
+				Name:        category.Name,
+				Description: category.Description,
+			}
+
+			for _, prod := range category.Products {
+				product := Product{
+					Name:        prod.Name,
+					Description: prod.Description,
+					ImageURL:    prod.FilePath,
+				}
+
+				// Process variations
+				for _, var_ := range prod.ProductVariations {
+					product.Variations = append(product.Variations, Variation{
+						Name:  var_.Name,
+						Price: var_.Price,
+					})
+				}
+
+				// If there's only one variation with no name, use it as the main price
+				if len(product.Variations) == 1 && product.Variations[0].Name == "" {
+					product.Price = product.Variations[0].Price
+					product.Variations = nil
+				}
+
+				menuCat.Products = append(menuCat.Products, product)
+			}
+
+			simplified.Menu = append(simplified.Menu, menuCat)
+		}
+	}
+
+	return simplified
+}
+
+// GetMenuHandler returns the simplified menu of a single vendor
 func GetMenuHandler(w http.ResponseWriter, r *http.Request) {
+	// Get parameters from URL
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Missing code parameter")
+		return
+	}
+
+	latitude, longitude, err := parseCoordinates(r.URL.Query())
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// Resolve the market from explicit params or the coordinates
+	market, err := foodpanda.ResolveMarket(r.URL.Query().Get("country"), r.URL.Query().Get("language"), latitude, longitude)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid market: "+err.Error())
+		return
+	}
+
+	// Serve from the menu store when possible
+	key := menustore.Key("foodpanda", market.Country, market.Language, code)
+	menuResp := &structure.FoodPandaMenuResponse{}
+	cacheStatus := foodpanda.CacheHit
+	found, err := menustore.GetJSON(menustore.Default, key, menuResp)
+	if err != nil {
+		log.Printf("Failed to read menu store: %v", err)
+	}
+
+	if !found {
+		cacheStatus = foodpanda.CacheMiss
+
+		// Fetch the vendor menu from Foodpanda
+		menuResp, err = foodpanda.DefaultClient.VendorMenu(r.Context(), code, foodpanda.MenuOptions{
+			Latitude:   latitude,
+			Longitude:  longitude,
+			Country:    market.Country,
+			LanguageID: market.LanguageID,
+		})
+		if err != nil {
+			apierror.WriteError(w, r, "Failed to fetch menu", err)
+			return
+		}
+
+		if err := menustore.SetJSON(menustore.Default, key, menuResp, menustore.DefaultTTL); err != nil {
+			log.Printf("Failed to write menu store: %v", err)
+		}
+	}
+
+	// Remember the opening hours for the picker's opening-hours filter
+	menuSchedules.remember(market.Country, code, menuResp)
+
+	simplified := transformResponse(menuResp)
+	simplified.Market = market
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache", cacheStatus)
+	json.NewEncoder(w).Encode(simplified)
+}
+
+// InvalidateMenuHandler drops a vendor's stored menus in every language so
+// the next request refetches them
+func InvalidateMenuHandler(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+	// Keys use the lowercase country codes ResolveMarket produces
+	country := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("country")))
+	if code == "" || country == "" {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Missing code or country parameters")
+		return
+	}
+
+	for _, namespace := range []string{"foodpanda", "summary"} {
+		for _, language := range foodpanda.Languages() {
+			if err := menustore.Default.Invalidate(menustore.Key(namespace, country, language, code)); err != nil {
+				apierror.WriteError(w, r, "Failed to invalidate menu", err)
+				return
+			}
+		}
+	}
 
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"what-to-eat/pkg/menustore"
+)
+
+func TestInvalidateMenuHandlerClearsEveryLanguage(t *testing.T) {
+	defer func(s menustore.MenuStore) { menustore.Default = s }(menustore.Default)
+	store := menustore.NewMemoryStore(0)
+	menustore.Default = store
+
+	for _, namespace := range []string{"foodpanda", "summary"} {
+		for _, language := range []string{"en", "zh"} {
+			store.Set(menustore.Key(namespace, "tw", language, "abcd"), []byte("{}"), time.Hour)
+		}
+	}
+	store.Set(menustore.Key("foodpanda", "tw", "zh", "efgh"), []byte("{}"), time.Hour)
+
+	r := httptest.NewRequest("DELETE", "/restaurants/menu?code=abcd&country=TW", nil)
+	w := httptest.NewRecorder()
+	InvalidateMenuHandler(w, r)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	for _, namespace := range []string{"foodpanda", "summary"} {
+		for _, language := range []string{"en", "zh"} {
+			if _, ok, _ := store.Get(menustore.Key(namespace, "tw", language, "abcd")); ok {
+				t.Errorf("%s menu in %s was not invalidated", namespace, language)
+			}
+		}
+	}
+	if _, ok, _ := store.Get(menustore.Key("foodpanda", "tw", "zh", "efgh")); !ok {
+		t.Error("another vendor's menu was invalidated")
+	}
+}
+
+func TestGetMenuHandlerValidatesCoordinates(t *testing.T) {
+	for _, query := range []string{"code=abcd", "code=abcd&latitude=north&longitude=121.5", "latitude=25&longitude=121.5"} {
+		w := httptest.NewRecorder()
+		GetMenuHandler(w, httptest.NewRequest("GET", "/restaurants/menu?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestInvalidateMenuRequiresAdminToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{"valid token", "secret", "Bearer secret", http.StatusNoContent},
+		{"wrong token", "secret", "Bearer guess", http.StatusUnauthorized},
+		{"missing header", "secret", "", http.StatusUnauthorized},
+		{"no token configured", "", "Bearer ", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		handler := RequireAdminToken(tt.token)(http.HandlerFunc(InvalidateMenuHandler))
+		r := httptest.NewRequest("DELETE", "/restaurants/menu?code=abcd&country=tw", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
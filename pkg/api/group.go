@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/cuisine"
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/ranking"
+	"what-to-eat/pkg/structure"
+)
+
+const maxGroupMembers = 10
+
+// Strategies the group picker can report
+const (
+	GroupIntersection = "intersection"
+	GroupCentroid     = "centroid"
+)
+
+// GroupPickRequestBody is the body of POST /picker/group
+type GroupPickRequestBody struct {
+	Locations []struct {
+		Name      string  `json:"name"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"locations"`
+	CuisineTypes []string `json:"cuisineTypes"`
+	Mode         string   `json:"mode"`
+	K            int      `json:"k"`
+	Scorer       string   `json:"scorer"`
+	Seed         *int64   `json:"seed"`
+	Country      string   `json:"country"`
+	Language     string   `json:"language"`
+}
+
+// GroupPickHandler picks one restaurant that delivers to every member. When
+// no vendor delivers to everyone it picks around the members' centroid.
+func GroupPickHandler(w http.ResponseWriter, r *http.Request) {
+	var body GroupPickRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if len(body.Locations) < 2 || len(body.Locations) > maxGroupMembers {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest,
+			fmt.Sprintf("locations must contain between 2 and %d entries", maxGroupMembers))
+		return
+	}
+
+	kStr := ""
+	if body.K > 0 {
+		kStr = fmt.Sprint(body.K)
+	}
+	selection, err := parseSelectionOptions(body.Mode, kStr)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	if selection.Scorer, err = ranking.Lookup(body.Scorer); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// Every member has to be in the same market
+	var market structure.Market
+	for i, location := range body.Locations {
+		memberMarket, err := foodpanda.ResolveMarket(body.Country, body.Language, location.Latitude, location.Longitude)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid market: "+err.Error())
+			return
+		}
+		if i > 0 && memberMarket != market {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "All locations must be in the same market")
+			return
+		}
+		market = memberMarket
+	}
+
+	var members []structure.Location
+	for _, location := range body.Locations {
+		members = append(members, structure.Location{Latitude: location.Latitude, Longitude: location.Longitude})
+	}
+
+	listings, err := fetchMemberListings(r.Context(), members, body.CuisineTypes, market)
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to fetch data", err)
+		return
+	}
+
+	// Intersect the member listings by vendor code
+	strategy := GroupIntersection
+	common := intersectListings(listings)
+
+	// Fall back to the vendors around the geographic centroid
+	if len(common) == 0 {
+		strategy = GroupCentroid
+		centroid := centroidOf(members)
+		centroidListing, err := fetchMemberListings(r.Context(), []structure.Location{centroid}, body.CuisineTypes, market)
+		if err != nil {
+			apierror.WriteError(w, r, "Failed to fetch data", err)
+			return
+		}
+		listings = centroidListing
+		common = intersectListings(listings)
+	}
+
+	if len(common) == 0 {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "No restaurant delivers to the whole group")
+		return
+	}
+
+	language := cuisine.Negotiate(r, market.Language)
+	var candidates []structure.Restaurant
+	for _, item := range common {
+		candidates = append(candidates, toRestaurant(item, language))
+	}
+	ranking.Apply(selection.Scorer, candidates, false)
+
+	var src randomSource = cryptoSource{}
+	if body.Seed != nil {
+		src = newSeededSource(*body.Seed)
+	}
+	chosen, selectionInfo, err := selectRestaurant(candidates, selection, src)
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to generate random selection", err)
+		return
+	}
+	selectionInfo.Seed = body.Seed
+
+	// Report what the pick means for each member
+	var memberInfos []structure.GroupMemberInfo
+	for i, location := range body.Locations {
+		info := structure.GroupMemberInfo{
+			Name:     location.Name,
+			Distance: haversineKm(location.Latitude, location.Longitude, chosen.Latitude, chosen.Longitude),
+		}
+		if strategy == GroupIntersection {
+			item := listings[i][chosen.Code]
+			info.Distance = item.Distance
+			info.DeliveryFee = item.MinimumDeliveryFee
+			info.DeliveryTime = item.MinimumDeliveryTime
+		} else {
+			// Fees were only quoted for the centroid
+			info.DeliveryFee = chosen.DeliveryFee
+			info.DeliveryTime = chosen.DeliveryTime
+			info.Estimated = true
+		}
+		memberInfos = append(memberInfos, info)
+	}
+
+	response := structure.GroupPickResponse{
+		Restaurant: chosen,
+		Members:    memberInfos,
+		Strategy:   strategy,
+		Market:     market,
+		Selection:  selectionInfo,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	json.NewEncoder(w).Encode(response)
+}
+
+// fetchMemberListings fetches the deliverable vendors around each location
+// concurrently, keyed by vendor code
+func fetchMemberListings(ctx context.Context, locations []structure.Location, cuisines []string, market structure.Market) ([]map[string]structure.RestaurantItem, error) {
+	listings := make([]map[string]structure.RestaurantItem, len(locations))
+	errs := make([]error, len(locations))
+	var wg sync.WaitGroup
+
+	for i, location := range locations {
+		wg.Add(1)
+		go func(i int, location structure.Location) {
+			defer wg.Done()
+
+			resp, _, err := foodpanda.DefaultClient.CachedListAllVendors(ctx, foodpanda.ListingOptions{
+				Latitude:   location.Latitude,
+				Longitude:  location.Longitude,
+				Cuisines:   cuisines,
+				Country:    market.Country,
+				LanguageID: market.LanguageID,
+			}, 0)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			listing := make(map[string]structure.RestaurantItem)
+			for _, item := range resp.Data.Items {
+				if item.Metadata.IsDeliveryAvailable && !item.Metadata.IsTemporaryClosed {
+					listing[item.Code] = item
+				}
+			}
+			listings[i] = listing
+		}(i, location)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return listings, nil
+}
+
+// intersectListings returns the vendors present in every listing, taking
+// the item from the first listing, in a stable order
+func intersectListings(listings []map[string]structure.RestaurantItem) []structure.RestaurantItem {
+	if len(listings) == 0 {
+		return nil
+	}
+
+	var common []structure.RestaurantItem
+	for code, item := range listings[0] {
+		inAll := true
+		for _, listing := range listings[1:] {
+			if _, ok := listing[code]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			common = append(common, item)
+		}
+	}
+
+	// Map iteration order is random; sort so seeded draws are reproducible
+	sortItemsByCode(common)
+	return common
+}
+
+// centroidOf returns the average of the locations, which is close enough to
+// the geographic centroid at city scale
+func centroidOf(locations []structure.Location) structure.Location {
+	var centroid structure.Location
+	for _, location := range locations {
+		centroid.Latitude += location.Latitude
+		centroid.Longitude += location.Longitude
+	}
+	centroid.Latitude /= float64(len(locations))
+	centroid.Longitude /= float64(len(locations))
+	return centroid
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// maxHistoryAge bounds how long picks are remembered regardless of the
+// window a request asks for
+const maxHistoryAge = 30 * 24 * time.Hour
+
+// maxHistoryClients and maxClientPicks bound how much history is kept
+const (
+	maxHistoryClients = 10000
+	maxClientPicks    = 200
+)
+
+// pickHistory remembers which restaurants were picked for each client
+type pickHistory struct {
+	mu    sync.Mutex
+	picks map[string][]pickRecord
+}
+
+type pickRecord struct {
+	Code     string
+	PickedAt time.Time
+}
+
+// recentPicks is the server-side pick history shared by the picker endpoints
+var recentPicks = &pickHistory{picks: make(map[string][]pickRecord)}
+
+// record remembers that code was picked for clientID. Once the history is
+// full, expired clients are swept and then the least recently active client
+// is forgotten to make room.
+func (h *pickHistory) record(clientID, code string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.picks[clientID]; !ok && len(h.picks) >= maxHistoryClients {
+		h.sweep()
+		if len(h.picks) >= maxHistoryClients {
+			h.evictOldest()
+		}
+	}
+
+	records := append(h.prune(h.picks[clientID]), pickRecord{Code: code, PickedAt: time.Now()})
+	if len(records) > maxClientPicks {
+		records = append([]pickRecord(nil), records[len(records)-maxClientPicks:]...)
+	}
+	h.picks[clientID] = records
+}
+
+// since returns the codes picked for clientID within window
+func (h *pickHistory) since(clientID string, window time.Duration) map[string]bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := h.prune(h.picks[clientID])
+	if len(records) == 0 {
+		delete(h.picks, clientID)
+	} else {
+		h.picks[clientID] = records
+	}
+
+	cutoff := time.Now().Add(-window)
+	codes := make(map[string]bool)
+	for _, record := range records {
+		if record.PickedAt.After(cutoff) {
+			codes[record.Code] = true
+		}
+	}
+	return codes
+}
+
+// prune drops records older than maxHistoryAge; records are in pick order
+func (h *pickHistory) prune(records []pickRecord) []pickRecord {
+	cutoff := time.Now().Add(-maxHistoryAge)
+	for i, record := range records {
+		if record.PickedAt.After(cutoff) {
+			return records[i:]
+		}
+	}
+	return nil
+}
+
+// sweep drops every client whose picks have all expired
+func (h *pickHistory) sweep() {
+	for clientID, records := range h.picks {
+		if records = h.prune(records); len(records) == 0 {
+			delete(h.picks, clientID)
+		} else {
+			h.picks[clientID] = records
+		}
+	}
+}
+
+// evictOldest forgets the client whose latest pick is the oldest
+func (h *pickHistory) evictOldest() {
+	var oldestClient string
+	var oldest time.Time
+	for clientID, records := range h.picks {
+		latest := records[len(records)-1].PickedAt
+		if oldestClient == "" || latest.Before(oldest) {
+			oldestClient, oldest = clientID, latest
+		}
+	}
+	delete(h.picks, oldestClient)
+}
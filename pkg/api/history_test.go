@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// useEmptyHistory gives the test its own pick history
+func useEmptyHistory(t *testing.T) {
+	t.Helper()
+	previous := recentPicks
+	recentPicks = &pickHistory{picks: make(map[string][]pickRecord)}
+	t.Cleanup(func() { recentPicks = previous })
+}
+
+func TestPickHistoryBounds(t *testing.T) {
+	h := &pickHistory{picks: make(map[string][]pickRecord)}
+
+	for i := 0; i < maxClientPicks+10; i++ {
+		h.record("busy", fmt.Sprintf("v%d", i))
+	}
+	if got := len(h.picks["busy"]); got != maxClientPicks {
+		t.Errorf("busy client has %d picks, want %d", got, maxClientPicks)
+	}
+	if recent := h.since("busy", time.Hour); recent["v0"] || !recent[fmt.Sprintf("v%d", maxClientPicks+9)] {
+		t.Error("oldest picks should be dropped first")
+	}
+
+	// Expired clients are swept before anyone active is evicted
+	h.picks["expired"] = []pickRecord{{Code: "x", PickedAt: time.Now().Add(-2 * maxHistoryAge)}}
+	for i := len(h.picks); i < maxHistoryClients; i++ {
+		h.record(fmt.Sprintf("client-%d", i), "v")
+	}
+	h.record("newcomer", "v")
+	if _, ok := h.picks["expired"]; ok {
+		t.Error("expired client survived the sweep")
+	}
+	if _, ok := h.picks["busy"]; !ok {
+		t.Error("active client was evicted while an expired one could go")
+	}
+
+	// A full history forgets the least recently active client
+	h.picks["busy"] = []pickRecord{{Code: "v", PickedAt: time.Now().Add(-time.Hour)}}
+	h.record("latecomer", "v")
+	if _, ok := h.picks["busy"]; ok {
+		t.Error("least recently active client was kept")
+	}
+	if len(h.picks) != maxHistoryClients {
+		t.Errorf("history holds %d clients, want %d", len(h.picks), maxHistoryClients)
+	}
+}
+
+func TestPickerRemembersOnlyOptedInClients(t *testing.T) {
+	useReplayClient(t)
+	useEmptyHistory(t)
+
+	if w, _ := pick(t, taipei101+"&clientId=plain"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if _, ok := recentPicks.picks["plain"]; ok {
+		t.Error("pick remembered for a client that didn't opt in to repeat avoidance")
+	}
+
+	if w, _ := pick(t, taipei101+"&clientId=careful&repeatPolicy=skip"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if got := len(recentPicks.picks["careful"]); got != 1 {
+		t.Errorf("opted-in client has %d picks remembered, want 1", got)
+	}
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/menustore"
+	"what-to-eat/pkg/structure"
+)
+
+const minutesPerDay = 24 * 60
+
+// hoursOptions controls opening-hours filtering. Temporarily closed vendors
+// are skipped unless IncludeClosed is set; a non-nil At keeps only vendors
+// open at that time.
+type hoursOptions struct {
+	IncludeClosed bool
+	At            *time.Time
+}
+
+// parseHoursOptions reads includeClosed and at. The target time is either
+// RFC 3339 or "HH:MM" in the market's local time, meaning the next time
+// that clock time comes around.
+func parseHoursOptions(q url.Values, market structure.Market, now time.Time) (hoursOptions, error) {
+	var opts hoursOptions
+	var err error
+
+	if value := q.Get("includeClosed"); value != "" {
+		opts.IncludeClosed, err = strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid includeClosed value: %s", value)
+		}
+	}
+
+	value := q.Get("at")
+	if value == "" {
+		return opts, nil
+	}
+
+	loc := foodpanda.Location(market.Country)
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		at = at.In(loc)
+		opts.At = &at
+		return opts, nil
+	}
+
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return opts, fmt.Errorf("invalid at value: %s", value)
+	}
+	local := now.In(loc)
+	at := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if at.Before(local) {
+		at = at.AddDate(0, 0, 1)
+	}
+	opts.At = &at
+	return opts, nil
+}
+
+// allows reports whether item passes the opening-hours filter. Vendors with
+// no known hours are given the benefit of the doubt, which is reported as
+// unknown so callers can tell how much the filter actually checked.
+func (h hoursOptions) allows(item structure.RestaurantItem, market structure.Market) (allowed, unknown bool) {
+	if item.Metadata.IsTemporaryClosed && !h.IncludeClosed {
+		return false, false
+	}
+	if h.At == nil {
+		return true, false
+	}
+
+	schedules := item.Schedules
+	if len(schedules) == 0 {
+		schedules = menuSchedules.get(market.Country, item.Code)
+	}
+	open, known := openAt(schedules, *h.At)
+	return open || !known, !known
+}
+
+// openAt reports whether any schedule covers t, and whether the schedules
+// held any usable window at all. Windows that close at or before they open
+// run past midnight into the next weekday.
+func openAt(schedules []structure.VendorSchedule, t time.Time) (open, known bool) {
+	weekday := isoWeekday(t.Weekday())
+	previous := weekday - 1
+	if previous == 0 {
+		previous = 7
+	}
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, schedule := range schedules {
+		opening, ok := parseClock(schedule.OpeningTime)
+		if !ok {
+			continue
+		}
+		closing, ok := parseClock(schedule.ClosingTime)
+		if !ok {
+			continue
+		}
+		known = true
+
+		overnight := closing <= opening
+		switch {
+		case schedule.Weekday == weekday && !overnight:
+			open = minute >= opening && minute < closing
+		case schedule.Weekday == weekday:
+			open = minute >= opening
+		case schedule.Weekday == previous && overnight:
+			open = minute < closing
+		}
+		if open {
+			return true, true
+		}
+	}
+	return false, known
+}
+
+// maxMenuSchedules bounds how many vendors' derived schedules are kept
+const maxMenuSchedules = 10000
+
+// scheduleCache holds daily windows derived from menus the menu endpoint has
+// served. The listing-wide filter reads it instead of the menu store, which
+// could mean thousands of menu reads per request.
+type scheduleCache struct {
+	mu      sync.Mutex
+	entries map[string]scheduleEntry
+}
+
+type scheduleEntry struct {
+	schedules []structure.VendorSchedule
+	expiresAt time.Time
+}
+
+// menuSchedules is shared by the menu endpoint and the picker filters
+var menuSchedules = &scheduleCache{entries: make(map[string]scheduleEntry)}
+
+// remember derives and stores the windows of a vendor's menu
+func (c *scheduleCache) remember(country, code string, menu *structure.FoodPandaMenuResponse) {
+	schedules := menuScheduleWindows(menu)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxMenuSchedules {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= maxMenuSchedules {
+			return
+		}
+	}
+	c.entries[country+":"+code] = scheduleEntry{
+		schedules: schedules,
+		expiresAt: now.Add(menustore.DefaultTTL),
+	}
+}
+
+// get returns the windows remembered for a vendor, if any
+func (c *scheduleCache) get(country, code string) []structure.VendorSchedule {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := country + ":" + code
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry.schedules
+}
+
+// menuScheduleWindows derives daily windows from a menu's opening times
+func menuScheduleWindows(menu *structure.FoodPandaMenuResponse) []structure.VendorSchedule {
+	var schedules []structure.VendorSchedule
+	for _, m := range menu.Data.Menus {
+		if m.OpeningTime == "" || m.ClosingTime == "" {
+			continue
+		}
+		for weekday := 1; weekday <= 7; weekday++ {
+			schedules = append(schedules, structure.VendorSchedule{
+				Weekday:     weekday,
+				OpeningTime: m.OpeningTime,
+				ClosingTime: m.ClosingTime,
+			})
+		}
+	}
+	return schedules
+}
+
+// parseClock converts "HH:MM" or "HH:MM:SS" into minutes after midnight.
+// "23:59" and "24:00" both mean the end of the day.
+func parseClock(value string) (int, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, false
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, false
+	}
+
+	total := hour*60 + minute
+	if total == minutesPerDay-1 {
+		total = minutesPerDay
+	}
+	if total > minutesPerDay {
+		return 0, false
+	}
+	return total, true
+}
+
+// isoWeekday numbers days from 1 (Monday) to 7 (Sunday)
+func isoWeekday(day time.Weekday) int {
+	if day == time.Sunday {
+		return 7
+	}
+	return int(day)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"what-to-eat/pkg/structure"
+)
+
+func TestHoursFilterUsesRememberedMenuSchedules(t *testing.T) {
+	market := structure.Market{Country: "tw"}
+	loc := time.FixedZone("TW", 8*60*60)
+	evening := time.Date(2026, 10, 14, 21, 0, 0, 0, loc)
+	opts := hoursOptions{At: &evening}
+
+	item := structure.RestaurantItem{Code: "lunchonly"}
+	if allowed, unknown := opts.allows(item, market); !allowed || !unknown {
+		t.Fatalf("allows = %v, %v; want vendors with unknown hours allowed and reported", allowed, unknown)
+	}
+
+	menu := &structure.FoodPandaMenuResponse{}
+	menu.Data.Menus = []structure.FoodPandaMenu{{OpeningTime: "11:00", ClosingTime: "14:00"}}
+	menuSchedules.remember(market.Country, item.Code, menu)
+	defer func() {
+		menuSchedules.mu.Lock()
+		menuSchedules.entries = make(map[string]scheduleEntry)
+		menuSchedules.mu.Unlock()
+	}()
+
+	if allowed, _ := opts.allows(item, market); allowed {
+		t.Error("vendor open 11:00-14:00 allowed at 21:00")
+	}
+	noon := time.Date(2026, 10, 14, 12, 0, 0, 0, loc)
+	if allowed, unknown := (hoursOptions{At: &noon}).allows(item, market); !allowed || unknown {
+		t.Errorf("allows = %v, %v at 12:00; want a known open vendor", allowed, unknown)
+	}
+}
+
+func TestOpenAtOvernightWindows(t *testing.T) {
+	loc := time.FixedZone("TW", 8*60*60)
+	// 2026-10-16 is a Friday (ISO weekday 5)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, loc)
+	}
+	lateFriday := []structure.VendorSchedule{{Weekday: 5, OpeningTime: "18:00", ClosingTime: "02:00"}}
+	sunday := []structure.VendorSchedule{{Weekday: 7, OpeningTime: "20:00", ClosingTime: "01:00"}}
+	allDay := []structure.VendorSchedule{{Weekday: 5, OpeningTime: "00:00", ClosingTime: "23:59"}}
+
+	tests := []struct {
+		name      string
+		schedules []structure.VendorSchedule
+		t         time.Time
+		open      bool
+		known     bool
+	}{
+		{"before an overnight window", lateFriday, at(16, 17, 59), false, true},
+		{"evening of an overnight window", lateFriday, at(16, 23, 30), true, true},
+		{"after midnight on the next day", lateFriday, at(17, 1, 30), true, true},
+		{"closing time itself", lateFriday, at(17, 2, 0), false, true},
+		{"the following night", lateFriday, at(17, 23, 30), false, true},
+		{"Sunday night wraps into Monday", sunday, at(19, 0, 30), true, true},
+		{"23:59 closes at the end of the day", allDay, at(16, 23, 59), true, true},
+		{"no schedules", nil, at(16, 12, 0), false, false},
+		{"unparseable schedules", []structure.VendorSchedule{{Weekday: 5, OpeningTime: "noon", ClosingTime: "14:00"}}, at(16, 12, 0), false, false},
+	}
+
+	for _, tt := range tests {
+		open, known := openAt(tt.schedules, tt.t)
+		if open != tt.open || known != tt.known {
+			t.Errorf("%s: openAt = %v, %v; want %v, %v", tt.name, open, known, tt.open, tt.known)
+		}
+	}
+}
+
+func TestParseHoursOptionsClockRollsOver(t *testing.T) {
+	market := structure.Market{Country: "tw"}
+	loc := time.FixedZone("TW", 8*60*60)
+	now := time.Date(2026, 10, 16, 20, 0, 0, 0, loc)
+
+	opts, err := parseHoursOptions(url.Values{"at": {"12:30"}}, market, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 17, 12, 30, 0, 0, loc); !opts.At.Equal(want) {
+		t.Errorf("at 12:30 after 20:00 = %v, want the next day %v", opts.At, want)
+	}
+
+	opts, err = parseHoursOptions(url.Values{"at": {"23:59"}}, market, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 16, 23, 59, 0, 0, loc); !opts.At.Equal(want) {
+		t.Errorf("at 23:59 = %v, want later today %v", opts.At, want)
+	}
+
+	if _, err := parseHoursOptions(url.Values{"at": {"25:00"}}, market, now); err == nil {
+		t.Error("at 25:00 accepted")
+	}
+}
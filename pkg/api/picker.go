@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"what-to-eat/pkg/cuisine"
+	"what-to-eat/pkg/experiment"
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/ranking"
+	"what-to-eat/pkg/structure"
+)
+
+// pickerRequest holds the parameters shared by the picker endpoints
+type pickerRequest struct {
+	Latitude    float64
+	Longitude   float64
+	ClientID    string
+	Market      structure.Market
+	Cuisines    []string
+	Selection   selectionOptions
+	Fulfillment string
+	Filter      restaurantFilter
+	ChainDedupe string
+	Exclusions  exclusionOptions
+	Hours       hoursOptions
+	Explain     bool
+	Experiment  *experiment.Assignment
+	Language    string
+	Seed        *int64
+}
+
+// pickerCandidates is the filtered set a picker draws from
+type pickerCandidates struct {
+	Restaurants    []structure.Restaurant
+	MergedBranches int
+	UnknownHours   int
+	CacheStatus    string
+}
+
+// parsePickerRequest reads the picker query parameters. Every error it
+// returns describes an invalid request.
+func parsePickerRequest(r *http.Request) (pickerRequest, error) {
+	var req pickerRequest
+	q := r.URL.Query()
+
+	var err error
+	if req.Latitude, req.Longitude, err = parseCoordinates(q); err != nil {
+		return req, err
+	}
+
+	// Resolve the market from explicit params or the coordinates
+	req.Market, err = foodpanda.ResolveMarket(q.Get("country"), q.Get("language"), req.Latitude, req.Longitude)
+	if err != nil {
+		return req, fmt.Errorf("Invalid market: %v", err)
+	}
+
+	if req.Selection, err = parseSelectionOptions(q.Get("mode"), q.Get("k")); err != nil {
+		return req, err
+	}
+	if req.Selection.Scorer, err = ranking.Lookup(q.Get("scorer")); err != nil {
+		return req, err
+	}
+	if value := q.Get("explain"); value != "" {
+		if req.Explain, err = strconv.ParseBool(value); err != nil {
+			return req, fmt.Errorf("invalid explain value: %s", value)
+		}
+	}
+	if req.Fulfillment, err = parseFulfillment(q.Get("fulfillment")); err != nil {
+		return req, err
+	}
+	req.Selection.NearestFirst = req.Fulfillment == FulfillmentPickup
+
+	if req.Filter, err = parseRestaurantFilter(q); err != nil {
+		return req, err
+	}
+	if req.ChainDedupe, err = parseChainDedupe(q.Get("dedupeChains")); err != nil {
+		return req, err
+	}
+	if req.Exclusions, err = parseExclusionOptions(q); err != nil {
+		return req, err
+	}
+	if req.Hours, err = parseHoursOptions(q, req.Market, time.Now()); err != nil {
+		return req, err
+	}
+	req.Language = cuisine.Negotiate(r, req.Market.Language)
+	req.ClientID = experiment.ClientID(r)
+
+	// Clients that don't choose a scorer may be enrolled in a ranking
+	// experiment; scorer names were validated when experiments were loaded
+	if q.Get("scorer") == "" {
+		if assignment, ok := experiment.Default.Assign(experiment.SurfacePicker, req.ClientID); ok {
+			req.Experiment = &assignment
+			if assignment.Variant.Scorer != "" {
+				req.Selection.Scorer, _ = ranking.Lookup(assignment.Variant.Scorer)
+			}
+		}
+	}
+
+	// An explicit seed switches to a reproducible draw
+	if seedStr := q.Get("seed"); seedStr != "" {
+		seed, err := strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			return req, fmt.Errorf("invalid seed value: %s", seedStr)
+		}
+		req.Seed = &seed
+	}
+
+	// Parse cuisine types
+	if cuisineTypesStr := q.Get("cuisineTypes"); cuisineTypesStr != "" {
+		req.Cuisines = strings.Split(cuisineTypesStr, ",")
+	}
+
+	return req, nil
+}
+
+// avoidsRepeats reports whether the client opted in to repeat avoidance.
+// Picks are only remembered for these clients.
+func (req pickerRequest) avoidsRepeats() bool {
+	return req.ClientID != "" && req.Exclusions.RecentWindow > 0
+}
+
+// exposeExperiment tags the response with the request's experiment variant,
+// if any, and records the exposure
+func (req pickerRequest) exposeExperiment(w http.ResponseWriter, restaurantCode string) *structure.ExperimentTag {
+	if req.Experiment == nil {
+		return nil
+	}
+	experiment.Expose(w, *req.Experiment, req.ClientID, restaurantCode)
+	return &structure.ExperimentTag{
+		Name:    req.Experiment.Experiment,
+		Variant: req.Experiment.Variant.Name,
+	}
+}
+
+// parseCoordinates reads the required latitude and longitude parameters
+func parseCoordinates(q url.Values) (float64, float64, error) {
+	// Get parameters from URL
+	latStr := q.Get("latitude")
+	lonStr := q.Get("longitude")
+
+	// Validate required parameters
+	if latStr == "" || lonStr == "" {
+		return 0, 0, fmt.Errorf("Missing latitude or longitude parameters")
+	}
+
+	// Convert string parameters to float64
+	latitude, err := strconv.ParseFloat(latStr, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Invalid latitude value: %v", err)
+	}
+	longitude, err := strconv.ParseFloat(lonStr, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Invalid longitude value: %v", err)
+	}
+
+	return latitude, longitude, nil
+}
+
+// randomSource returns the source draws should use for this request
+func (req pickerRequest) randomSource() randomSource {
+	if req.Seed != nil {
+		return newSeededSource(*req.Seed)
+	}
+	return cryptoSource{}
+}
+
+// loadCandidates fetches the vendor listing and applies every filter the
+// request asked for
+func loadCandidates(ctx context.Context, req pickerRequest) (pickerCandidates, error) {
+	var result pickerCandidates
+
+	// Fetch vendors from Foodpanda
+	foodpandaResp, cacheStatus, err := foodpanda.DefaultClient.CachedListAllVendors(ctx, foodpanda.ListingOptions{
+		Latitude:   req.Latitude,
+		Longitude:  req.Longitude,
+		Cuisines:   req.Cuisines,
+		Country:    req.Market.Country,
+		LanguageID: req.Market.LanguageID,
+	}, 0)
+	if err != nil {
+		return result, err
+	}
+	result.CacheStatus = cacheStatus
+
+	// Process restaurants and filter available ones
+	var availableRestaurants []structure.Restaurant
+	unknownHours := make(map[string]bool)
+	for _, item := range foodpandaResp.Data.Items {
+		if !fulfillable(item, req.Fulfillment) || !req.Filter.matches(item) || req.Exclusions.excluded(item) {
+			continue
+		}
+		if open, unknown := req.Hours.allows(item, req.Market); open {
+			if unknown {
+				unknownHours[item.Code] = true
+			}
+			restaurant := toRestaurant(item, req.Language)
+			if req.Fulfillment == FulfillmentPickup {
+				restaurant.WalkingDistance = haversineKm(req.Latitude, req.Longitude, item.Latitude, item.Longitude)
+				restaurant.WalkingTime = walkingMinutes(restaurant.WalkingDistance)
+			}
+			availableRestaurants = append(availableRestaurants, restaurant)
+		}
+	}
+
+	// Foodpanda may reorder the listing between calls; sort so the same seed
+	// draws from the same sequence
+	if req.Seed != nil {
+		sortRestaurantsByCode(availableRestaurants)
+	}
+
+	// Weigh the candidates against each other
+	ranking.Apply(req.Selection.Scorer, availableRestaurants, req.Explain)
+
+	// Pickup candidates are ranked by how far we have to walk
+	if req.Fulfillment == FulfillmentPickup {
+		sort.SliceStable(availableRestaurants, func(i, j int) bool {
+			return availableRestaurants[i].WalkingDistance < availableRestaurants[j].WalkingDistance
+		})
+	}
+
+	// Collapse chains so large chains don't dominate the draw
+	availableRestaurants, result.MergedBranches = dedupeChains(availableRestaurants, req.ChainDedupe)
+
+	// Avoid restaurants this client was given recently
+	if req.avoidsRepeats() {
+		recent := recentPicks.since(req.ClientID, req.Exclusions.RecentWindow)
+		availableRestaurants = avoidRecent(availableRestaurants, recent, req.Exclusions.RepeatPolicy)
+	}
+
+	// Report how many candidates the opening-hours filter couldn't check
+	for _, restaurant := range availableRestaurants {
+		if unknownHours[restaurant.Code] {
+			result.UnknownHours++
+		}
+	}
+
+	result.Restaurants = availableRestaurants
+	return result, nil
+}
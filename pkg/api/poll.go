@@ -0,0 +1,232 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/poll"
+	"what-to-eat/pkg/structure"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultPollMinutes = 30
+	maxPollMinutes     = 24 * 60
+)
+
+// CreatePollHandler opens a poll room seeded with a shortlist. It accepts
+// every picker query parameter plus count and durationMinutes, and an
+// optional JSON body with a title.
+func CreatePollHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := parsePickerRequest(r)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	var body struct {
+		Title string `json:"title"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body: "+err.Error())
+			return
+		}
+	}
+
+	count := defaultShortlistSize
+	if countStr := r.URL.Query().Get("count"); countStr != "" {
+		count, err = strconv.Atoi(countStr)
+		if err != nil || count < 1 || count > maxShortlistSize {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest,
+				fmt.Sprintf("count must be between 1 and %d", maxShortlistSize))
+			return
+		}
+	}
+
+	minutes := defaultPollMinutes
+	if minutesStr := r.URL.Query().Get("durationMinutes"); minutesStr != "" {
+		minutes, err = strconv.Atoi(minutesStr)
+		if err != nil || minutes < 1 || minutes > maxPollMinutes {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest,
+				fmt.Sprintf("durationMinutes must be between 1 and %d", maxPollMinutes))
+			return
+		}
+	}
+
+	candidates, err := loadCandidates(r.Context(), req)
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to fetch data", err)
+		return
+	}
+	if len(candidates.Restaurants) == 0 {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "No available restaurants found")
+		return
+	}
+
+	shortlist, _, err := selectShortlist(candidates.Restaurants, req.Selection, count, req.randomSource())
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to generate random selection", err)
+		return
+	}
+
+	room, err := poll.DefaultManager.Create(body.Title, shortlist, time.Now().Add(time.Duration(minutes)*time.Minute))
+	if err != nil {
+		writePollError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(room.State())
+}
+
+// GetPollHandler returns the current state of a poll
+func GetPollHandler(w http.ResponseWriter, r *http.Request) {
+	room, err := poll.DefaultManager.Get(chi.URLParam(r, "roomID"))
+	if err != nil {
+		writePollError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(room.State())
+}
+
+// AddPollCandidateHandler adds a restaurant to an open poll
+func AddPollCandidateHandler(w http.ResponseWriter, r *http.Request) {
+	room, err := poll.DefaultManager.Get(chi.URLParam(r, "roomID"))
+	if err != nil {
+		writePollError(w, r, err)
+		return
+	}
+
+	var body struct {
+		Member     string               `json:"member"`
+		Restaurant structure.Restaurant `json:"restaurant"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if body.Restaurant.Code == "" || body.Restaurant.Name == "" {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Restaurant code and name are required")
+		return
+	}
+
+	state, err := room.AddCandidate(body.Member, body.Restaurant)
+	if err != nil {
+		writePollError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// VotePollHandler records a member's vote
+func VotePollHandler(w http.ResponseWriter, r *http.Request) {
+	room, err := poll.DefaultManager.Get(chi.URLParam(r, "roomID"))
+	if err != nil {
+		writePollError(w, r, err)
+		return
+	}
+
+	var body struct {
+		Member string `json:"member"`
+		Code   string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if body.Member == "" || body.Code == "" {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Member and code are required")
+		return
+	}
+
+	state, err := room.Vote(body.Member, body.Code)
+	if err != nil {
+		writePollError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// PollEventsHandler streams live tallies as Server-Sent Events. Each change
+// is sent as a "tally" event; the final state is sent as a "closed" event.
+func PollEventsHandler(w http.ResponseWriter, r *http.Request) {
+	room, err := poll.DefaultManager.Get(chi.URLParam(r, "roomID"))
+	if err != nil {
+		writePollError(w, r, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	updates, unsubscribe := room.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case state, open := <-updates:
+			if !open {
+				return
+			}
+			event := "tally"
+			if state.Closed {
+				event = "closed"
+			}
+			if err := writeEvent(w, event, state); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes a single Server-Sent Event with a JSON payload
+func writeEvent(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// writePollError maps poll errors to HTTP responses
+func writePollError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, poll.ErrRoomNotFound):
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, poll.ErrUnknownCandidate):
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+	case errors.Is(err, poll.ErrRoomClosed),
+		errors.Is(err, poll.ErrDuplicateCandidate),
+		errors.Is(err, poll.ErrTooManyCandidates):
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeConflict, err.Error())
+	case errors.Is(err, poll.ErrTooManyRooms):
+		apierror.Write(w, r, http.StatusServiceUnavailable, apierror.CodeUnavailable, err.Error())
+	default:
+		apierror.WriteError(w, r, "Poll request failed", err)
+	}
+}
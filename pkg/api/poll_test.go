@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"what-to-eat/pkg/poll"
+)
+
+func createPoll(t *testing.T, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	CreatePollHandler(w, httptest.NewRequest("POST", "/api/v1/polls?"+query, nil))
+	return w
+}
+
+func TestCreatePollReplay(t *testing.T) {
+	useReplayClient(t)
+	previous := poll.DefaultManager
+	poll.DefaultManager = poll.NewManager()
+	defer func() { poll.DefaultManager = previous }()
+
+	w := createPoll(t, taipei101+"&count=3")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var state poll.State
+	if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(state.Candidates) != 3 || state.Closed || state.Winner != nil {
+		t.Errorf("state = %+v, want an open poll with 3 candidates", state)
+	}
+
+	// Every deliverable vendor excluded leaves nothing to vote on
+	if w := createPoll(t, taipei101+"&excludeVendors=t1ra,b2ce,b2cf,h3pt"); w.Code != http.StatusNotFound {
+		t.Errorf("empty poll: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
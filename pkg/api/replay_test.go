@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/replay"
+	"what-to-eat/pkg/structure"
+)
+
+// The fixtures in testdata/replay hold a six-vendor listing around Taipei
+// 101 and a rate-limited listing near Taipei Main Station. One vendor is
+// temporarily closed and one only offers pickup.
+const (
+	taipei101   = "latitude=25.033964&longitude=121.564468"
+	mainStation = "latitude=25.047700&longitude=121.517100"
+)
+
+// useReplayClient points the shared Foodpanda client at the recorded
+// fixtures for the duration of the test
+func useReplayClient(t *testing.T) {
+	t.Helper()
+	transport, err := replay.New(replay.ModeReplay, "testdata/replay", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := foodpanda.DefaultConfig()
+	config.Transport = transport
+	config.MaxRetries = 0
+
+	previous := foodpanda.DefaultClient
+	foodpanda.DefaultClient = foodpanda.NewClient(config)
+	t.Cleanup(func() { foodpanda.DefaultClient = previous })
+}
+
+func pick(t *testing.T, query string) (*httptest.ResponseRecorder, structure.ApiResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	RandomRestaurantHandler(w, httptest.NewRequest("GET", "/api/v1/picker/random?"+query, nil))
+
+	var resp structure.ApiResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return w, resp
+}
+
+func TestRandomRestaurantReplay(t *testing.T) {
+	useReplayClient(t)
+
+	w, resp := pick(t, taipei101)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if len(resp.Restaurants) != 1 {
+		t.Fatalf("got %d restaurants, want 1", len(resp.Restaurants))
+	}
+	deliverable := map[string]bool{"t1ra": true, "b2ce": true, "b2cf": true, "h3pt": true}
+	if code := resp.Restaurants[0].Code; !deliverable[code] {
+		t.Errorf("picked %s, which is closed or pickup-only", code)
+	}
+	if resp.Market.Country != "tw" || resp.Cache != foodpanda.CacheMiss {
+		t.Errorf("market = %s, cache = %s; want tw and a miss", resp.Market.Country, resp.Cache)
+	}
+	if resp.Selection.Candidates != 4 || resp.Selection.Probability != 0.25 {
+		t.Errorf("selection = %+v, want 4 candidates at 0.25", resp.Selection)
+	}
+
+	// The second request is served from the listing cache
+	if w, _ := pick(t, taipei101); w.Header().Get("X-Cache") != foodpanda.CacheHit {
+		t.Errorf("X-Cache = %q on repeat, want %q", w.Header().Get("X-Cache"), foodpanda.CacheHit)
+	}
+}
+
+func TestRandomRestaurantReplayOptions(t *testing.T) {
+	useReplayClient(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		candidates int
+		merged     int
+	}{
+		{"pickup", "&fulfillment=pickup", 3, 0},
+		{"chain dedupe", "&dedupeChains=rating", 3, 1},
+		{"including closed vendors", "&includeClosed=true", 5, 0},
+		{"excluded vendor", "&excludeVendors=t1ra", 3, 0},
+	}
+
+	for _, tt := range tests {
+		w, resp := pick(t, taipei101+tt.query)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status = %d: %s", tt.name, w.Code, w.Body)
+			continue
+		}
+		if resp.Selection.Candidates != tt.candidates || resp.Selection.MergedBranches != tt.merged {
+			t.Errorf("%s: %d candidates with %d merged, want %d with %d",
+				tt.name, resp.Selection.Candidates, resp.Selection.MergedBranches, tt.candidates, tt.merged)
+		}
+	}
+}
+
+func TestRandomRestaurantReplayIsReproducibleWithSeed(t *testing.T) {
+	useReplayClient(t)
+
+	var first []string
+	for seed := 1; seed <= 5; seed++ {
+		_, resp := pick(t, taipei101+"&mode=weighted&seed="+strconv.Itoa(seed))
+		first = append(first, resp.Restaurants[0].Code)
+	}
+
+	// A fresh client has an empty cache, so this replays the listing again
+	useReplayClient(t)
+	for seed := 1; seed <= 5; seed++ {
+		_, resp := pick(t, taipei101+"&mode=weighted&seed="+strconv.Itoa(seed))
+		if code := resp.Restaurants[0].Code; code != first[seed-1] {
+			t.Errorf("seed %d picked %s, then %s", seed, first[seed-1], code)
+		}
+	}
+}
+
+func TestRandomRestaurantReplayUpstreamErrors(t *testing.T) {
+	useReplayClient(t)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		code   string
+	}{
+		{"recorded rate limit", mainStation, http.StatusTooManyRequests, string(apierror.KindRateLimited)},
+		{"unrecorded request", "latitude=25.1&longitude=121.5", http.StatusServiceUnavailable, string(apierror.KindUnavailable)},
+		{"invalid request", "latitude=north", http.StatusBadRequest, apierror.CodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		w, _ := pick(t, tt.query)
+		var body apierror.Response
+		json.NewDecoder(w.Body).Decode(&body)
+		if w.Code != tt.status || body.Error.Code != tt.code {
+			t.Errorf("%s: %d %s, want %d %s", tt.name, w.Code, body.Error.Code, tt.status, tt.code)
+		}
+	}
+}
+
+func TestRandomRestaurantReplayReportsUnknownHours(t *testing.T) {
+	useReplayClient(t)
+
+	// Only Ramen Taro's hours are known, from a menu served earlier
+	menu := &structure.FoodPandaMenuResponse{}
+	menu.Data.Menus = []structure.FoodPandaMenu{{OpeningTime: "11:00", ClosingTime: "14:00"}}
+	menuSchedules.remember("tw", "t1ra", menu)
+	defer func() {
+		menuSchedules.mu.Lock()
+		menuSchedules.entries = make(map[string]scheduleEntry)
+		menuSchedules.mu.Unlock()
+	}()
+
+	tests := []struct {
+		at         string
+		candidates int
+	}{
+		{"2026-10-14T12:00:00%2B08:00", 4},
+		{"2026-10-14T21:00:00%2B08:00", 3},
+	}
+	for _, tt := range tests {
+		w, resp := pick(t, taipei101+"&at="+tt.at)
+		if w.Code != http.StatusOK {
+			t.Fatalf("at %s: status = %d: %s", tt.at, w.Code, w.Body)
+		}
+		if resp.Selection.Candidates != tt.candidates || resp.Selection.UnknownHours != 3 {
+			t.Errorf("at %s: %d candidates with %d of unknown hours, want %d with 3",
+				tt.at, resp.Selection.Candidates, resp.Selection.UnknownHours, tt.candidates)
+		}
+	}
+
+	if _, resp := pick(t, taipei101); resp.Selection.UnknownHours != 0 {
+		t.Errorf("unknown_hours = %d without at, want 0", resp.Selection.UnknownHours)
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"what-to-eat/pkg/ranking"
+	"what-to-eat/pkg/structure"
+)
+
+// Selection modes for the random picker
+const (
+	ModeUniform  = "uniform"
+	ModeWeighted = "weighted"
+	ModeTopK     = "topk"
+)
+
+const defaultTopK = 5
+
+// selectionOptions controls how a restaurant is drawn from the candidates
+type selectionOptions struct {
+	Mode   string
+	TopK   int
+	Scorer ranking.Scorer
+	// NearestFirst ranks top-k candidates by walking distance instead of weight
+	NearestFirst bool
+}
+
+// parseSelectionOptions reads the mode and k query parameters
+func parseSelectionOptions(mode, kStr string) (selectionOptions, error) {
+	opts := selectionOptions{Mode: mode, TopK: defaultTopK, Scorer: ranking.Default}
+	if opts.Mode == "" {
+		opts.Mode = ModeUniform
+	}
+
+	switch opts.Mode {
+	case ModeUniform, ModeWeighted:
+	case ModeTopK:
+		if kStr != "" {
+			k, err := strconv.Atoi(kStr)
+			if err != nil || k <= 0 {
+				return opts, fmt.Errorf("invalid k value: %s", kStr)
+			}
+			opts.TopK = k
+		}
+	default:
+		return opts, fmt.Errorf("unknown mode: %s", opts.Mode)
+	}
+
+	return opts, nil
+}
+
+// scorerName reports the scorer behind the weights, which only matters to
+// modes that use them
+func (opts selectionOptions) scorerName() string {
+	if opts.Mode == ModeUniform || opts.Scorer == nil {
+		return ""
+	}
+	return opts.Scorer.Name()
+}
+
+// cryptoRandFloat returns a cryptographically secure float in [0, 1)
+func cryptoRandFloat() (float64, error) {
+	n, err := cryptoRandInt(1 << 53)
+	if err != nil {
+		return 0, err
+	}
+	return float64(n) / (1 << 53), nil
+}
+
+// randomSource supplies the randomness for a draw
+type randomSource interface {
+	Intn(n int) (int, error)
+	Float64() (float64, error)
+}
+
+// cryptoSource draws from crypto/rand
+type cryptoSource struct{}
+
+func (cryptoSource) Intn(n int) (int, error) {
+	return cryptoRandInt(n)
+}
+
+func (cryptoSource) Float64() (float64, error) {
+	return cryptoRandFloat()
+}
+
+// seededSource is a deterministic PRNG so the same seed and candidates
+// always produce the same draw
+type seededSource struct {
+	rng *rand.Rand
+}
+
+func newSeededSource(seed int64) seededSource {
+	return seededSource{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (s seededSource) Intn(n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("max must be positive")
+	}
+	return s.rng.Intn(n), nil
+}
+
+func (s seededSource) Float64() (float64, error) {
+	return s.rng.Float64(), nil
+}
+
+// selectRestaurant draws one restaurant according to opts and returns it with
+// the probability it had of being drawn
+func selectRestaurant(candidates []structure.Restaurant, opts selectionOptions, src randomSource) (structure.Restaurant, structure.SelectionInfo, error) {
+	info := structure.SelectionInfo{
+		Mode:       opts.Mode,
+		Scorer:     opts.scorerName(),
+		Candidates: len(candidates),
+	}
+	if len(candidates) == 0 {
+		return structure.Restaurant{}, info, fmt.Errorf("no candidates to select from")
+	}
+
+	switch opts.Mode {
+	case ModeWeighted:
+		var total float64
+		for _, candidate := range candidates {
+			total += candidate.Weight
+		}
+		// Fall back to a uniform draw when no candidate has any weight
+		if total <= 0 {
+			break
+		}
+
+		target, err := src.Float64()
+		if err != nil {
+			return structure.Restaurant{}, info, err
+		}
+		target *= total
+
+		chosen := candidates[len(candidates)-1]
+		var cumulative float64
+		for _, candidate := range candidates {
+			cumulative += candidate.Weight
+			if target < cumulative {
+				chosen = candidate
+				break
+			}
+		}
+		info.Probability = chosen.Weight / total
+		return chosen, info, nil
+
+	case ModeTopK:
+		ranked := append([]structure.Restaurant(nil), candidates...)
+		sort.SliceStable(ranked, func(i, j int) bool {
+			if opts.NearestFirst {
+				return ranked[i].WalkingDistance < ranked[j].WalkingDistance
+			}
+			return ranked[i].Weight > ranked[j].Weight
+		})
+		if len(ranked) > opts.TopK {
+			ranked = ranked[:opts.TopK]
+		}
+		info.TopK = opts.TopK
+		candidates = ranked
+	}
+
+	randIndex, err := src.Intn(len(candidates))
+	if err != nil {
+		return structure.Restaurant{}, info, err
+	}
+	info.Probability = 1 / float64(len(candidates))
+
+	return candidates[randIndex], info, nil
+}
+
+// selectShortlist draws up to count distinct restaurants, each draw made
+// from the candidates not yet chosen
+func selectShortlist(candidates []structure.Restaurant, opts selectionOptions, count int, src randomSource) ([]structure.Restaurant, structure.SelectionInfo, error) {
+	info := structure.SelectionInfo{
+		Mode:       opts.Mode,
+		Scorer:     opts.scorerName(),
+		Candidates: len(candidates),
+	}
+	if opts.Mode == ModeTopK {
+		info.TopK = opts.TopK
+	}
+
+	remaining := append([]structure.Restaurant(nil), candidates...)
+	var shortlist []structure.Restaurant
+	for len(shortlist) < count && len(remaining) > 0 {
+		chosen, drawInfo, err := selectRestaurant(remaining, opts, src)
+		if err != nil {
+			return nil, info, err
+		}
+		shortlist = append(shortlist, chosen)
+		info.Probabilities = append(info.Probabilities, drawInfo.Probability)
+
+		// Remove the chosen restaurant so it can't be drawn again
+		for i := range remaining {
+			if remaining[i].ID == chosen.ID {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if len(info.Probabilities) > 0 {
+		info.Probability = info.Probabilities[0]
+	}
+	return shortlist, info, nil
+}
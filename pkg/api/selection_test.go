@@ -0,0 +1,97 @@
+package api
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"what-to-eat/pkg/structure"
+)
+
+func weighted(weights ...float64) []structure.Restaurant {
+	var candidates []structure.Restaurant
+	for i, w := range weights {
+		candidates = append(candidates, structure.Restaurant{ID: i + 1, Code: string(rune('a' + i)), Weight: w})
+	}
+	return candidates
+}
+
+func TestSelectRestaurantProbabilities(t *testing.T) {
+	candidates := weighted(1, 3, 0, 4)
+	const draws = 20000
+
+	tests := []struct {
+		name string
+		opts selectionOptions
+		want map[string]float64
+	}{
+		{"uniform", selectionOptions{Mode: ModeUniform}, map[string]float64{"a": 0.25, "b": 0.25, "c": 0.25, "d": 0.25}},
+		{"weighted", selectionOptions{Mode: ModeWeighted}, map[string]float64{"a": 0.125, "b": 0.375, "d": 0.5}},
+		{"top-2", selectionOptions{Mode: ModeTopK, TopK: 2}, map[string]float64{"b": 0.5, "d": 0.5}},
+	}
+
+	for _, tt := range tests {
+		src := newSeededSource(1)
+		counts := make(map[string]int)
+		for i := 0; i < draws; i++ {
+			chosen, info, err := selectRestaurant(candidates, tt.opts, src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts[chosen.Code]++
+			if want := tt.want[chosen.Code]; math.Abs(info.Probability-want) > 1e-9 {
+				t.Fatalf("%s: reported probability %v for %s, want %v", tt.name, info.Probability, chosen.Code, want)
+			}
+		}
+		for code, want := range tt.want {
+			if got := float64(counts[code]) / draws; math.Abs(got-want) > 0.02 {
+				t.Errorf("%s: %s drawn %.3f of the time, want %.3f", tt.name, code, got, want)
+			}
+		}
+		for code := range counts {
+			if _, ok := tt.want[code]; !ok {
+				t.Errorf("%s: drew %s, which should have no chance", tt.name, code)
+			}
+		}
+	}
+}
+
+func TestSelectRestaurantWeightedFallsBackToUniform(t *testing.T) {
+	_, info, err := selectRestaurant(weighted(0, 0), selectionOptions{Mode: ModeWeighted}, newSeededSource(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Probability != 0.5 {
+		t.Errorf("probability = %v, want 0.5", info.Probability)
+	}
+}
+
+func TestSelectShortlistIsDistinctAndReproducible(t *testing.T) {
+	candidates := weighted(1, 2, 3, 4, 5)
+	opts := selectionOptions{Mode: ModeWeighted}
+
+	first, info, err := selectShortlist(candidates, opts, 3, newSeededSource(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 3 || len(info.Probabilities) != 3 {
+		t.Fatalf("got %d restaurants and %d probabilities, want 3 of each", len(first), len(info.Probabilities))
+	}
+	seen := make(map[string]bool)
+	for _, r := range first {
+		if seen[r.Code] {
+			t.Errorf("%s drawn twice", r.Code)
+		}
+		seen[r.Code] = true
+	}
+
+	again, _, _ := selectShortlist(candidates, opts, 3, newSeededSource(42))
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("same seed gave %v then %v", first, again)
+	}
+
+	all, _, _ := selectShortlist(candidates, opts, 10, newSeededSource(42))
+	if len(all) != len(candidates) {
+		t.Errorf("asking for more than available returned %d, want %d", len(all), len(candidates))
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/structure"
+)
+
+const (
+	defaultShortlistSize = 3
+	maxShortlistSize     = 10
+)
+
+// ShortlistHandler returns several distinct restaurants to choose between.
+// It accepts every random picker parameter plus count.
+func ShortlistHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := parsePickerRequest(r)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	count := defaultShortlistSize
+	if countStr := r.URL.Query().Get("count"); countStr != "" {
+		count, err = strconv.Atoi(countStr)
+		if err != nil || count < 1 || count > maxShortlistSize {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest,
+				fmt.Sprintf("count must be between 1 and %d", maxShortlistSize))
+			return
+		}
+	}
+
+	candidates, err := loadCandidates(r.Context(), req)
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to fetch data", err)
+		return
+	}
+
+	if len(candidates.Restaurants) == 0 {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "No available restaurants found")
+		return
+	}
+
+	shortlist, selectionInfo, err := selectShortlist(candidates.Restaurants, req.Selection, count, req.randomSource())
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to generate random selection", err)
+		return
+	}
+
+	selectionInfo.MergedBranches = candidates.MergedBranches
+	selectionInfo.UnknownHours = candidates.UnknownHours
+	selectionInfo.Seed = req.Seed
+
+	apiResponse := structure.ApiResponse{
+		Restaurants: shortlist,
+		Market:      req.Market,
+		Cache:       candidates.CacheStatus,
+		Selection:   selectionInfo,
+		Fulfillment: req.Fulfillment,
+		OrderAt:     req.Hours.At,
+		Language:    req.Language,
+		Experiment:  req.exposeExperiment(w, shortlist[0].Code),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("X-Cache", candidates.CacheStatus)
+
+	json.NewEncoder(w).Encode(apiResponse)
+}
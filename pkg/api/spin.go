@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/structure"
+)
+
+// Roulette pacing: each frame waits a little longer than the last
+const (
+	defaultSpinFrames = 20
+	maxSpinFrames     = 50
+	spinStartDelay    = 40 * time.Millisecond
+	spinMaxDelay      = 700 * time.Millisecond
+	spinSlowdown      = 1.18
+)
+
+// SpinFrame is one step of the roulette animation
+type SpinFrame struct {
+	Index      int                  `json:"index"`
+	Restaurant structure.Restaurant `json:"restaurant"`
+	DelayMs    int64                `json:"delayMs"`
+}
+
+// SpinHandler streams a roulette spin as Server-Sent Events. The real
+// selection is made up front; "frame" events then show decelerating draws
+// from the same candidates, and a final "result" event carries the pick in
+// the same shape as the random endpoint. It accepts every random picker
+// parameter plus frames.
+func SpinHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := parsePickerRequest(r)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	frames := defaultSpinFrames
+	if framesStr := r.URL.Query().Get("frames"); framesStr != "" {
+		frames, err = strconv.Atoi(framesStr)
+		if err != nil || frames < 0 || frames > maxSpinFrames {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest,
+				fmt.Sprintf("frames must be between 0 and %d", maxSpinFrames))
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Streaming unsupported")
+		return
+	}
+
+	candidates, err := loadCandidates(r.Context(), req)
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to fetch data", err)
+		return
+	}
+
+	if len(candidates.Restaurants) == 0 {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "No available restaurants found")
+		return
+	}
+
+	src := req.randomSource()
+	chosen, selectionInfo, err := selectRestaurant(candidates.Restaurants, req.Selection, src)
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to generate random selection", err)
+		return
+	}
+
+	selectionInfo.MergedBranches = candidates.MergedBranches
+	selectionInfo.UnknownHours = candidates.UnknownHours
+	selectionInfo.Seed = req.Seed
+
+	spin, err := spinSequence(candidates.Restaurants, chosen, frames, src)
+	if err != nil {
+		apierror.WriteError(w, r, "Failed to generate random selection", err)
+		return
+	}
+
+	if req.avoidsRepeats() {
+		recentPicks.record(req.ClientID, chosen.Code)
+	}
+
+	// Tag before the stream starts so the header still goes out
+	tag := req.exposeExperiment(w, chosen.Code)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Cache", candidates.CacheStatus)
+
+	for _, frame := range spin {
+		if err := writeEvent(w, "frame", frame); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		timer := time.NewTimer(time.Duration(frame.DelayMs) * time.Millisecond)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	writeEvent(w, "result", structure.ApiResponse{
+		Restaurants: []structure.Restaurant{chosen},
+		Market:      req.Market,
+		Cache:       candidates.CacheStatus,
+		Selection:   selectionInfo,
+		Fulfillment: req.Fulfillment,
+		OrderAt:     req.Hours.At,
+		Language:    req.Language,
+		Experiment:  tag,
+	})
+	flusher.Flush()
+}
+
+// spinSequence draws the animation frames. Frames are uniform over the
+// candidates, never repeat the previous frame when there is a choice, and
+// never show the final pick on the last frame so the result still lands.
+func spinSequence(candidates []structure.Restaurant, chosen structure.Restaurant, frames int, src randomSource) ([]SpinFrame, error) {
+	spin := make([]SpinFrame, 0, frames)
+	delay := spinStartDelay
+	previous := ""
+
+	for i := 0; i < frames; i++ {
+		var pick structure.Restaurant
+		for attempt := 0; ; attempt++ {
+			index, err := src.Intn(len(candidates))
+			if err != nil {
+				return nil, err
+			}
+			pick = candidates[index]
+			if len(candidates) == 1 || attempt >= 8 {
+				break
+			}
+			if pick.Code == previous || (i == frames-1 && pick.Code == chosen.Code) {
+				continue
+			}
+			break
+		}
+
+		spin = append(spin, SpinFrame{
+			Index:      i,
+			Restaurant: pick,
+			DelayMs:    delay.Milliseconds(),
+		})
+		previous = pick.Code
+
+		delay = time.Duration(float64(delay) * spinSlowdown)
+		if delay > spinMaxDelay {
+			delay = spinMaxDelay
+		}
+	}
+
+	return spin, nil
+}
@@ -0,0 +1,122 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Kind classifies upstream failures
+type Kind string
+
+const (
+	KindUnavailable Kind = "upstream_unavailable"
+	KindRateLimited Kind = "upstream_rate_limited"
+	KindBlocked     Kind = "upstream_blocked"
+	KindMalformed   Kind = "upstream_malformed"
+	KindTimeout     Kind = "upstream_timeout"
+	KindNotFound    Kind = "upstream_not_found"
+	KindRejected    Kind = "upstream_rejected"
+)
+
+// Error is a failure talking to an upstream service such as Foodpanda or Vertex AI
+type Error struct {
+	Kind       Kind
+	Upstream   string
+	StatusCode int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s: %s (status %d): %v", e.Upstream, e.Kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Upstream, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus maps the error kind to the status we answer our clients with
+func (e *Error) HTTPStatus() int {
+	switch e.Kind {
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindRejected:
+		// Upstream refused the request, usually over a parameter our client sent
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// Retryable reports whether repeating the request may succeed
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case KindUnavailable, KindRateLimited, KindTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// New creates an upstream error of the given kind
+func New(kind Kind, upstream string, err error) *Error {
+	return &Error{Kind: kind, Upstream: upstream, Err: err}
+}
+
+// FromStatus classifies a non-2xx upstream response
+func FromStatus(upstream string, statusCode int, body []byte) *Error {
+	if len(body) > 200 {
+		body = body[:200]
+	}
+
+	kind := KindUnavailable
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		kind = KindRateLimited
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		kind = KindBlocked
+	case statusCode == http.StatusGatewayTimeout || statusCode == http.StatusRequestTimeout:
+		kind = KindTimeout
+	case statusCode == http.StatusNotFound || statusCode == http.StatusGone:
+		kind = KindNotFound
+	case statusCode < 500:
+		kind = KindRejected
+	}
+
+	return &Error{
+		Kind:       kind,
+		Upstream:   upstream,
+		StatusCode: statusCode,
+		Err:        fmt.Errorf("unexpected status %d: %s", statusCode, body),
+	}
+}
+
+// FromTransport classifies an error returned while sending a request
+func FromTransport(upstream string, err error) *Error {
+	var upstreamErr *Error
+	if errors.As(err, &upstreamErr) {
+		return upstreamErr
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return New(KindTimeout, upstream, err)
+	}
+	return New(KindUnavailable, upstream, err)
+}
+
+// Malformed wraps a decoding failure of an upstream response
+func Malformed(upstream string, err error) *Error {
+	return New(KindMalformed, upstream, err)
+}
@@ -0,0 +1,54 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestFromStatus(t *testing.T) {
+	tests := []struct {
+		status     int
+		kind       Kind
+		httpStatus int
+		retryable  bool
+	}{
+		{http.StatusNotFound, KindNotFound, http.StatusNotFound, false},
+		{http.StatusBadRequest, KindRejected, http.StatusBadRequest, false},
+		{http.StatusUnprocessableEntity, KindRejected, http.StatusBadRequest, false},
+		{http.StatusForbidden, KindBlocked, http.StatusBadGateway, false},
+		{http.StatusTooManyRequests, KindRateLimited, http.StatusTooManyRequests, true},
+		{http.StatusGatewayTimeout, KindTimeout, http.StatusGatewayTimeout, true},
+		{http.StatusInternalServerError, KindUnavailable, http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		err := FromStatus("test", tt.status, []byte("body"))
+		if err.Kind != tt.kind {
+			t.Errorf("FromStatus(%d).Kind = %s, want %s", tt.status, err.Kind, tt.kind)
+		}
+		if got := err.HTTPStatus(); got != tt.httpStatus {
+			t.Errorf("FromStatus(%d).HTTPStatus() = %d, want %d", tt.status, got, tt.httpStatus)
+		}
+		if got := err.Retryable(); got != tt.retryable {
+			t.Errorf("FromStatus(%d).Retryable() = %v, want %v", tt.status, got, tt.retryable)
+		}
+	}
+}
+
+func TestFromTransport(t *testing.T) {
+	if err := FromTransport("test", fmt.Errorf("dial: %w", context.DeadlineExceeded)); err.Kind != KindTimeout {
+		t.Errorf("deadline exceeded classified as %s", err.Kind)
+	}
+	if err := FromTransport("test", errors.New("connection refused")); err.Kind != KindUnavailable {
+		t.Errorf("connection error classified as %s", err.Kind)
+	}
+
+	// Already classified errors pass through unchanged
+	original := New(KindBlocked, "test", errors.New("blocked"))
+	if err := FromTransport("other", fmt.Errorf("wrapped: %w", original)); err != original {
+		t.Errorf("FromTransport rewrapped an upstream error: %v", err)
+	}
+}
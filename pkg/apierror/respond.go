@@ -0,0 +1,58 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Codes for errors that do not come from an upstream service
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeUnauthorized   = "unauthorized"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeUnavailable    = "unavailable"
+	CodeInternal       = "internal_error"
+)
+
+// Response is the JSON body of every error response
+type Response struct {
+	Error struct {
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id,omitempty"`
+	} `json:"error"`
+}
+
+// Write sends a JSON error body with the given status and code
+func Write(w http.ResponseWriter, r *http.Request, status int, code string, message string) {
+	var response Response
+	response.Error.Code = code
+	response.Error.Message = message
+	response.Error.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if response.Error.RequestID != "" {
+		w.Header().Set("X-Request-Id", response.Error.RequestID)
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// WriteError sends err, using its upstream kind and status when it is an
+// *Error and a 500 otherwise. message prefixes the error text.
+func WriteError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	var upstreamErr *Error
+	if errors.As(err, &upstreamErr) {
+		Write(w, r, upstreamErr.HTTPStatus(), string(upstreamErr.Kind), message+": "+err.Error())
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	Write(w, r, http.StatusInternalServerError, CodeInternal, message+": "+err.Error())
+}
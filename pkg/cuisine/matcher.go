@@ -0,0 +1,294 @@
+package cuisine
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"what-to-eat/pkg/structure"
+)
+
+// Match weights: naming a cuisine outright counts for more than describing
+// a craving several cuisines satisfy
+const (
+	directWeight  = 2
+	conceptWeight = 1
+)
+
+// synonyms name a single cuisine, in addition to its taxonomy labels
+var synonyms = map[int][]string{
+	163:  {"sandwich", "sandwiches", "toast", "三明治", "吐司", "土司"},
+	164:  {"japanese", "japan", "日式", "日本", "和食", "日料"},
+	165:  {"pizza", "披薩", "比薩"},
+	166:  {"chinese", "中式", "中華", "中餐"},
+	168:  {"thai", "泰式", "泰國", "打拋", "tom yum", "冬蔭"},
+	171:  {"international", "exotic", "異國", "異國料理"},
+	176:  {"dessert", "sweets", "甜點", "甜食"},
+	177:  {"burger", "hamburger", "漢堡"},
+	179:  {"western", "american", "european", "歐美", "美式", "西式", "西餐"},
+	180:  {"sushi", "sashimi", "壽司", "生魚片"},
+	181:  {"drink", "beverage", "bubble tea", "boba", "milk tea", "juice", "飲料", "手搖", "珍奶", "奶茶", "果汁"},
+	186:  {"vegetarian", "vegan", "plant-based", "素食", "蔬食", "吃素"},
+	188:  {"korean", "korea", "kimchi", "bibimbap", "韓式", "韓國", "韓料", "泡菜", "拌飯"},
+	189:  {"teppanyaki", "鐵板燒", "鐵板"},
+	193:  {"vietnamese", "pho", "banh mi", "越式", "越南", "河粉"},
+	195:  {"pasta", "spaghetti", "italian", "義大利麵", "義式", "意大利麵"},
+	198:  {"breakfast", "brunch", "早餐", "早午餐"},
+	199:  {"soup", "湯品"},
+	201:  {"noodle", "麵食"},
+	214:  {"street food", "snack", "小吃", "夜市"},
+	225:  {"healthy", "salad", "low calorie", "low-calorie", "diet", "健康", "沙拉", "輕食", "低卡"},
+	235:  {"hong kong", "cantonese", "dim sum", "港式", "茶餐廳", "燒臘", "廣東"},
+	248:  {"taiwanese", "taiwan", "台式", "台灣", "臺灣", "古早味"},
+	252:  {"southeast asian", "malaysian", "indonesian", "singaporean", "東南亞", "南洋", "馬來", "印尼", "新加坡"},
+	1202: {"congee", "porridge", "粥", "稀飯"},
+	1203: {"ramen", "拉麵"},
+	1206: {"coffee", "latte", "cafe", "espresso", "咖啡", "拿鐵"},
+	1208: {"dumpling", "gyoza", "餃子", "水餃", "煎餃", "鍋貼"},
+	1209: {"fried chicken", "炸雞"},
+	1210: {"rice bowl", "donburi", "丼", "蓋飯"},
+	1211: {"steak", "牛排"},
+	1212: {"curry", "咖哩", "咖喱"},
+	1214: {"hot pot", "hotpot", "shabu", "火鍋", "麻辣鍋", "涮涮鍋", "鍋物"},
+	1215: {"bento", "lunch box", "便當", "飯盒"},
+	1216: {"cake", "蛋糕"},
+	1220: {"lu wei", "braised", "滷味", "滷"},
+	1221: {"chicken cutlet", "popcorn chicken", "鹹酥雞", "雞排"},
+	1227: {"fried rice", "炒飯"},
+	1233: {"douhua", "tofu pudding", "豆花"},
+	1236: {"bbq", "barbecue", "grill", "grilled", "燒烤", "烤肉", "串燒"},
+	1241: {"donut", "doughnut", "甜甜圈"},
+}
+
+// concepts describe cravings that several cuisines satisfy
+var concepts = map[string][]int{
+	"spicy":      {1214, 168, 1212, 188, 1220, 252},
+	"spice":      {1214, 168, 1212, 188, 1220, 252},
+	"辣":          {1214, 168, 1212, 188, 1220, 252},
+	"soupy":      {199, 1214, 1203, 201, 1202, 193},
+	"brothy":     {199, 1214, 1203, 201, 1202, 193},
+	"湯":          {199, 1214, 1203, 201, 1202, 193},
+	"rice":       {1210, 1215, 1227, 1212, 1202},
+	"飯":          {1210, 1215, 1227, 1212, 1202},
+	"麵":          {201, 1203, 195, 193},
+	"meat":       {1211, 1236, 1209, 177},
+	"肉":          {1211, 1236, 1209, 177},
+	"seafood":    {180, 1214},
+	"fish":       {180, 1214},
+	"海鮮":         {180, 1214},
+	"sweet":      {176, 1216, 1241, 1233, 181},
+	"甜":          {176, 1216, 1241, 1233, 181},
+	"cold":       {181, 1233, 176},
+	"refreshing": {181, 1233, 176, 225},
+	"冰":          {181, 1233, 176},
+	"涼":          {181, 1233, 176},
+	"warm":       {199, 1214, 1202},
+	"comforting": {199, 1214, 1202},
+	"暖":          {199, 1214, 1202},
+	"quick":      {1215, 214, 163},
+	"cheap":      {1215, 214, 163},
+	"便宜":         {1215, 214, 163},
+	"light":      {225, 1202, 199, 186},
+	"清淡":         {225, 1202, 199, 186},
+	"fried":      {1209, 1221, 177},
+	"greasy":     {1209, 1221, 177},
+	"crispy":     {1209, 1221, 177},
+	"炸":          {1209, 1221, 177},
+	"酥":          {1209, 1221},
+	"late night": {1221, 1220, 214, 1236},
+	"宵夜":         {1221, 1220, 214, 1236},
+	"cheese":     {165, 195},
+	"起司":         {165, 195},
+	"chicken":    {1209, 1221},
+	"雞":          {1209, 1221},
+}
+
+// negations mark a keyword the user wants to avoid when they appear earlier
+// in the same clause. Only explicit phrases count: a bare 不 is as likely to
+// be an intensifier (辣到不行) as a refusal.
+var negations = []string{
+	"no ", "not ", "without ", "except ", "anything but ", "don't want ", "hate ",
+	"不要", "不想", "不太想", "不吃", "不能吃", "別吃", "別點", "除了",
+}
+
+// falseNegations contain a negation phrase without negating anything, as in
+// the question forms 要不要 and 想不想
+var falseNegations = []string{"不錯", "不管", "不論", "不行", "不知道", "要不要", "想不想", "吃不吃"}
+
+// clauseBreaks end the reach of a negation. Whitespace after CJK text is
+// treated as a break as well; see clauseStart.
+var clauseBreaks = []string{",", ".", ";", "!", "?", " but ", " and ", "，", "。", "；", "！", "？", "、", "但", "可是", "還有"}
+
+// Match picks the available categories a free-text preference asks for,
+// best match first. A cuisine ruled out by name ("no curry", "不要咖哩") is
+// never returned; a craving ruled out ("不要辣") only counts against the
+// cuisines it describes. It returns an empty slice when nothing matches.
+func Match(preference string, available []structure.CuisineLabel) []structure.CuisineLabel {
+	text := strings.ToLower(preference)
+	scores := make(map[int]int)
+	excluded := make(map[int]bool)
+
+	apply := func(keyword string, ids []int, weight int) {
+		for _, at := range find(text, strings.ToLower(keyword)) {
+			isNegated := negated(text[:at])
+			for _, id := range ids {
+				switch {
+				case !isNegated:
+					scores[id] += weight
+				case weight == directWeight:
+					excluded[id] = true
+				default:
+					scores[id] -= weight
+				}
+			}
+		}
+	}
+
+	for _, c := range available {
+		keywords := append([]string{c.Label}, synonyms[c.ID]...)
+		for _, lang := range Languages {
+			if label, ok := labels[c.ID][lang]; ok {
+				keywords = append(keywords, label)
+			}
+		}
+		for _, keyword := range dedupe(keywords) {
+			apply(keyword, []int{c.ID}, directWeight)
+		}
+	}
+	for keyword, ids := range concepts {
+		apply(keyword, ids, conceptWeight)
+	}
+
+	type scored struct {
+		cuisine structure.CuisineLabel
+		score   int
+	}
+	var matches []scored
+	for _, c := range available {
+		if score := scores[c.ID]; score > 0 && !excluded[c.ID] {
+			matches = append(matches, scored{c, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]structure.CuisineLabel, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, m.cuisine)
+	}
+	return result
+}
+
+// find returns the byte offsets where keyword occurs in text. Latin keywords
+// must stand as whole words, allowing a trailing plural s.
+func find(text, keyword string) []int {
+	if keyword == "" {
+		return nil
+	}
+
+	var offsets []int
+	for start := 0; start < len(text); {
+		i := strings.Index(text[start:], keyword)
+		if i < 0 {
+			break
+		}
+		at := start + i
+		end := at + len(keyword)
+		start = end
+
+		if isLatin(keyword) {
+			before, _ := utf8.DecodeLastRuneInString(text[:at])
+			if at > 0 && unicode.IsLetter(before) {
+				continue
+			}
+			if strings.HasPrefix(text[end:], "s") {
+				end++
+			}
+			after, _ := utf8.DecodeRuneInString(text[end:])
+			if end < len(text) && unicode.IsLetter(after) {
+				continue
+			}
+		}
+		offsets = append(offsets, at)
+	}
+	return offsets
+}
+
+// negated reports whether the clause leading up to a keyword negates it
+func negated(prefix string) bool {
+	for _, b := range clauseBreaks {
+		if i := strings.LastIndex(prefix, b); i >= 0 {
+			prefix = prefix[i+len(b):]
+		}
+	}
+	prefix = prefix[clauseStart(prefix):]
+	for _, f := range falseNegations {
+		prefix = strings.ReplaceAll(prefix, f, "")
+	}
+	for _, n := range negations {
+		if containsPhrase(prefix, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPhrase reports whether text contains phrase. Latin phrases must
+// start a word, so the "no " in "cappuccino " doesn't count.
+func containsPhrase(text, phrase string) bool {
+	if !isLatin(phrase) {
+		return strings.Contains(text, phrase)
+	}
+	for start := 0; start < len(text); {
+		i := strings.Index(text[start:], phrase)
+		if i < 0 {
+			return false
+		}
+		at := start + i
+		before, _ := utf8.DecodeLastRuneInString(text[:at])
+		if at == 0 || !unicode.IsLetter(before) {
+			return true
+		}
+		start = at + 1
+	}
+	return false
+}
+
+// clauseStart returns the offset after the last whitespace that follows a
+// CJK character. Chinese has no word spacing, so a space there separates
+// clauses, while in English it only separates words ("not spicy").
+func clauseStart(text string) int {
+	start := 0
+	var previous rune
+	for i, r := range text {
+		if unicode.IsSpace(r) && previous > unicode.MaxLatin1 {
+			start = i + utf8.RuneLen(r)
+		}
+		previous = r
+	}
+	return start
+}
+
+func isLatin(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxLatin1 {
+			return false
+		}
+	}
+	return true
+}
+
+func dedupe(keywords []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, k := range keywords {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k != "" && !seen[k] {
+			seen[k] = true
+			unique = append(unique, k)
+		}
+	}
+	return unique
+}
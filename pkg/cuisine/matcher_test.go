@@ -0,0 +1,105 @@
+package cuisine
+
+import (
+	"reflect"
+	"testing"
+
+	"what-to-eat/pkg/structure"
+)
+
+// allCuisines offers every taxonomy entry with its Chinese label
+func allCuisines() []structure.CuisineLabel {
+	var available []structure.CuisineLabel
+	for _, e := range entries {
+		available = append(available, structure.CuisineLabel{ID: e.ID, Label: e.Zh})
+	}
+	return available
+}
+
+func matchedIDs(preference string) []int {
+	var ids []int
+	for _, c := range Match(preference, allCuisines()) {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func contains(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMatchNamedCuisines(t *testing.T) {
+	tests := []struct {
+		preference string
+		want       []int
+		not        []int
+	}{
+		{"我不知道要吃火鍋還是拉麵", []int{1214, 1203}, nil},
+		{"辣到不行的火鍋", []int{1214}, nil},
+		{"不太想吃便當 想吃火鍋", []int{1214}, []int{1215}},
+		{"不要便當，想吃火鍋", []int{1214}, []int{1215}},
+		{"要不要吃火鍋", []int{1214}, nil},
+		{"no curry, I want thai", []int{168}, []int{1212}},
+		{"I'd like noodles or dumplings", []int{201, 1208}, nil},
+		{"a cappuccino with cake", []int{1216}, nil},
+		{"i want ramen, casino style ramen", []int{1203}, nil},
+		{"i hate curry", nil, []int{1212}},
+	}
+
+	for _, tt := range tests {
+		ids := matchedIDs(tt.preference)
+		for _, id := range tt.want {
+			if !contains(ids, id) {
+				t.Errorf("Match(%q) = %v, want it to include %d", tt.preference, ids, id)
+			}
+		}
+		for _, id := range tt.not {
+			if contains(ids, id) {
+				t.Errorf("Match(%q) = %v, want it to exclude %d", tt.preference, ids, id)
+			}
+		}
+	}
+}
+
+func TestMatchRanksSharedCravingsFirst(t *testing.T) {
+	ids := matchedIDs("something spicy and soupy")
+	if len(ids) == 0 || ids[0] != 1214 {
+		t.Fatalf("Match(spicy and soupy) = %v, want hot pot (1214) first", ids)
+	}
+}
+
+func TestMatchNegatedCravingOnlyPenalises(t *testing.T) {
+	// Not wanting it spicy must not rule out the hot pot the user named
+	if ids := matchedIDs("想吃火鍋但不要太辣"); !reflect.DeepEqual(ids, []int{1214}) {
+		t.Errorf("Match = %v, want [1214]", ids)
+	}
+
+	ids := matchedIDs("not spicy, something soupy")
+	if contains(ids, 1214) {
+		t.Errorf("Match = %v, want hot pot cancelled out", ids)
+	}
+	if !contains(ids, 199) {
+		t.Errorf("Match = %v, want soup (199)", ids)
+	}
+}
+
+func TestMatchWholeWords(t *testing.T) {
+	for _, preference := range []string{"steakhouse", "gibberish", ""} {
+		if ids := matchedIDs(preference); len(ids) != 0 {
+			t.Errorf("Match(%q) = %v, want nothing", preference, ids)
+		}
+	}
+}
+
+func TestMatchOnlyReturnsAvailable(t *testing.T) {
+	available := []structure.CuisineLabel{{ID: 1203, Label: "拉麵"}}
+	got := Match("hot pot or ramen", available)
+	if !reflect.DeepEqual(got, available) {
+		t.Errorf("Match = %v, want %v", got, available)
+	}
+}
@@ -0,0 +1,75 @@
+package cuisine
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Negotiate picks the label language for a request: the lang query
+// parameter wins, then the best supported Accept-Language entry, then
+// fallback
+func Negotiate(r *http.Request, fallback string) string {
+	if lang, ok := supported(r.URL.Query().Get("lang")); ok {
+		return lang
+	}
+	if lang, ok := parseAcceptLanguage(r.Header.Get("Accept-Language")); ok {
+		return lang
+	}
+	return fallback
+}
+
+// supported reduces a language tag such as zh-TW or en_US to a supported
+// base language
+func supported(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i > 0 {
+		tag = tag[:i]
+	}
+	for _, lang := range Languages {
+		if tag == lang {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// parseAcceptLanguage returns the supported language with the highest
+// quality value in an Accept-Language header
+func parseAcceptLanguage(header string) (string, bool) {
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		lang, ok := supported(fields[0])
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{lang, quality})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].lang, true
+}
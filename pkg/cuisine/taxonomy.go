@@ -0,0 +1,96 @@
+package cuisine
+
+// Supported label languages
+const (
+	LanguageChinese  = "zh"
+	LanguageEnglish  = "en"
+	LanguageJapanese = "ja"
+)
+
+// Languages lists the label languages in order of preference when a client
+// accepts several equally
+var Languages = []string{LanguageChinese, LanguageEnglish, LanguageJapanese}
+
+// entry is one cuisine in the taxonomy. Chinese labels match the titles
+// Foodpanda Taiwan returns.
+type entry struct {
+	ID int
+	Zh string
+	En string
+	Ja string
+}
+
+var entries = []entry{
+	{163, "三明治 / 吐司", "Sandwiches & Toast", "サンドイッチ・トースト"},
+	{164, "日式", "Japanese", "和食"},
+	{165, "披薩", "Pizza", "ピザ"},
+	{166, "中式", "Chinese", "中華料理"},
+	{168, "泰式", "Thai", "タイ料理"},
+	{171, "異國", "International", "各国料理"},
+	{176, "甜點", "Desserts", "デザート"},
+	{177, "漢堡", "Burgers", "ハンバーガー"},
+	{179, "歐美", "Western", "洋食"},
+	{180, "壽司", "Sushi", "寿司"},
+	{181, "飲料", "Drinks", "ドリンク"},
+	{186, "素食", "Vegetarian", "ベジタリアン"},
+	{188, "韓式", "Korean", "韓国料理"},
+	{189, "鐵板燒", "Teppanyaki", "鉄板焼き"},
+	{193, "越式", "Vietnamese", "ベトナム料理"},
+	{195, "義大利麵", "Pasta", "パスタ"},
+	{198, "早餐", "Breakfast", "朝食"},
+	{199, "湯品", "Soup", "スープ"},
+	{201, "麵食", "Noodles", "麺類"},
+	{214, "小吃", "Street Food", "屋台料理"},
+	{225, "健康餐", "Healthy", "ヘルシー"},
+	{235, "港式", "Hong Kong", "香港料理"},
+	{248, "台式", "Taiwanese", "台湾料理"},
+	{252, "東南亞", "Southeast Asian", "東南アジア料理"},
+	{1202, "粥", "Congee", "お粥"},
+	{1203, "拉麵", "Ramen", "ラーメン"},
+	{1206, "咖啡", "Coffee", "コーヒー"},
+	{1208, "餃子", "Dumplings", "餃子"},
+	{1209, "炸雞", "Fried Chicken", "フライドチキン"},
+	{1210, "丼飯/蓋飯", "Rice Bowls", "丼もの"},
+	{1211, "牛排", "Steak", "ステーキ"},
+	{1212, "咖哩", "Curry", "カレー"},
+	{1214, "火鍋", "Hot Pot", "火鍋"},
+	{1215, "便當", "Bento", "弁当"},
+	{1216, "蛋糕", "Cakes", "ケーキ"},
+	{1220, "滷味", "Braised Snacks (Lu Wei)", "台湾風煮込み（滷味）"},
+	{1221, "鹹酥雞/雞排", "Taiwanese Fried Chicken", "台湾唐揚げ・鶏排"},
+	{1227, "炒飯", "Fried Rice", "チャーハン"},
+	{1233, "豆花", "Tofu Pudding (Douhua)", "豆花"},
+	{1236, "燒烤", "BBQ", "バーベキュー"},
+	{1241, "甜甜圈", "Donuts", "ドーナツ"},
+}
+
+// labels maps cuisine ID to language to label
+var labels = make(map[int]map[string]string, len(entries))
+
+func init() {
+	for _, e := range entries {
+		labels[e.ID] = map[string]string{
+			LanguageChinese:  e.Zh,
+			LanguageEnglish:  e.En,
+			LanguageJapanese: e.Ja,
+		}
+	}
+}
+
+// Label returns the cuisine's label in language, or fallback when the
+// cuisine or language isn't in the taxonomy
+func Label(id int, language, fallback string) string {
+	if label, ok := labels[id][language]; ok {
+		return label
+	}
+	return fallback
+}
+
+// IDs returns every cuisine ID in the taxonomy
+func IDs() []int {
+	ids := make([]int, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
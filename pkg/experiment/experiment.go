@@ -0,0 +1,204 @@
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"what-to-eat/pkg/ranking"
+)
+
+// Surfaces an experiment can target
+const (
+	SurfacePicker     = "picker"
+	SurfaceFilter     = "filter"
+	SurfaceSuggestion = "suggestion"
+)
+
+// promptVersions are the prompt versions a variant may select on each
+// surface, registered by the packages that own the prompts
+var promptVersions = make(map[string]map[string]bool)
+
+// RegisterPromptVersion makes version selectable by variants of experiments
+// on surface. Call it from an init function so it runs before experiments
+// are loaded.
+func RegisterPromptVersion(surface, version string) {
+	if promptVersions[surface] == nil {
+		promptVersions[surface] = make(map[string]bool)
+	}
+	promptVersions[surface][version] = true
+}
+
+// Variant is one arm of an experiment. Empty fields leave the server
+// default in place, so a control arm usually sets nothing but its name.
+type Variant struct {
+	Name          string `json:"name"`
+	Weight        int    `json:"weight,omitempty"`
+	Scorer        string `json:"scorer,omitempty"`
+	PromptVersion string `json:"prompt_version,omitempty"`
+	Model         string `json:"model,omitempty"`
+}
+
+// Experiment splits the clients of one surface between variants
+type Experiment struct {
+	Name     string    `json:"name"`
+	Surface  string    `json:"surface"`
+	Variants []Variant `json:"variants"`
+}
+
+// Assignment is the variant a client was given
+type Assignment struct {
+	Experiment string
+	Variant    Variant
+}
+
+// Assign deterministically picks a variant for clientID. The experiment
+// name is part of the hash so clients are reshuffled between experiments.
+func (e Experiment) Assign(clientID string) Variant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(e.Name + "/" + clientID))
+	bucket := int(h.Sum64() % uint64(total))
+
+	for _, v := range e.Variants {
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1]
+}
+
+// Registry holds the configured experiments
+type Registry struct {
+	experiments []Experiment
+}
+
+// Default is empty until main loads a configuration, so nothing is tagged
+var Default = &Registry{}
+
+// NewRegistry validates experiments and gives unweighted variants a weight
+// of 1
+func NewRegistry(experiments []Experiment) (*Registry, error) {
+	names := make(map[string]bool)
+	for i := range experiments {
+		e := &experiments[i]
+		if e.Name == "" {
+			return nil, fmt.Errorf("experiment %d has no name", i)
+		}
+		if names[e.Name] {
+			return nil, fmt.Errorf("duplicate experiment: %s", e.Name)
+		}
+		names[e.Name] = true
+
+		switch e.Surface {
+		case SurfacePicker, SurfaceFilter, SurfaceSuggestion:
+		default:
+			return nil, fmt.Errorf("experiment %s: unknown surface: %s", e.Name, e.Surface)
+		}
+		if len(e.Variants) < 2 {
+			return nil, fmt.Errorf("experiment %s needs at least two variants", e.Name)
+		}
+
+		variants := make(map[string]bool)
+		for j := range e.Variants {
+			v := &e.Variants[j]
+			if v.Name == "" || variants[v.Name] {
+				return nil, fmt.Errorf("experiment %s: variant names must be unique and non-empty", e.Name)
+			}
+			variants[v.Name] = true
+			if v.Weight < 0 {
+				return nil, fmt.Errorf("experiment %s: variant %s has a negative weight", e.Name, v.Name)
+			}
+			if v.Weight == 0 {
+				v.Weight = 1
+			}
+			if v.Scorer != "" {
+				if _, err := ranking.Lookup(v.Scorer); err != nil {
+					return nil, fmt.Errorf("experiment %s: variant %s: %w", e.Name, v.Name, err)
+				}
+			}
+			if v.PromptVersion != "" && !promptVersions[e.Surface][v.PromptVersion] {
+				return nil, fmt.Errorf("experiment %s: variant %s: unknown prompt version: %s", e.Name, v.Name, v.PromptVersion)
+			}
+		}
+	}
+	return &Registry{experiments: experiments}, nil
+}
+
+// LoadRegistry reads a JSON array of experiments from path
+func LoadRegistry(path string) (*Registry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read experiments: %w", err)
+	}
+	var experiments []Experiment
+	if err := json.Unmarshal(raw, &experiments); err != nil {
+		return nil, fmt.Errorf("failed to parse experiments: %w", err)
+	}
+	return NewRegistry(experiments)
+}
+
+// Assign returns the client's variant in the first experiment on surface.
+// Anonymous clients are never enrolled.
+func (r *Registry) Assign(surface, clientID string) (Assignment, bool) {
+	if clientID == "" {
+		return Assignment{}, false
+	}
+	for _, e := range r.experiments {
+		if e.Surface == surface {
+			return Assignment{Experiment: e.Name, Variant: e.Assign(clientID)}, true
+		}
+	}
+	return Assignment{}, false
+}
+
+// Get looks an experiment up by name
+func (r *Registry) Get(name string) (Experiment, bool) {
+	for _, e := range r.experiments {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Experiment{}, false
+}
+
+// List returns every configured experiment
+func (r *Registry) List() []Experiment {
+	return append([]Experiment{}, r.experiments...)
+}
+
+// ClientID identifies the caller for assignment, from the clientId query
+// parameter or the X-Client-Id header
+func ClientID(r *http.Request) string {
+	if id := r.URL.Query().Get("clientId"); id != "" {
+		return id
+	}
+	return r.Header.Get("X-Client-Id")
+}
+
+// Expose tags the response with the assignment and records that the client
+// saw it. A failed write is logged rather than failing the request.
+func Expose(w http.ResponseWriter, a Assignment, clientID, restaurantCode string) {
+	w.Header().Set("X-Experiment", a.Experiment+"/"+a.Variant.Name)
+
+	err := DefaultStore.Record(Outcome{
+		Experiment:     a.Experiment,
+		Variant:        a.Variant.Name,
+		ClientID:       clientID,
+		Event:          EventExposure,
+		RestaurantCode: restaurantCode,
+		Time:           time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to record experiment exposure: %v", err)
+	}
+}
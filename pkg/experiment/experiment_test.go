@@ -0,0 +1,69 @@
+package experiment
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func variants(vs ...Variant) []Variant { return vs }
+
+func TestNewRegistryValidation(t *testing.T) {
+	RegisterPromptVersion(SurfaceFilter, "test-v1")
+
+	tests := []struct {
+		name    string
+		exp     Experiment
+		wantErr string
+	}{
+		{"valid picker", Experiment{Name: "a", Surface: SurfacePicker, Variants: variants(Variant{Name: "control"}, Variant{Name: "wilson", Scorer: "wilson"})}, ""},
+		{"valid prompt", Experiment{Name: "b", Surface: SurfaceFilter, Variants: variants(Variant{Name: "control"}, Variant{Name: "new", PromptVersion: "test-v1"})}, ""},
+		{"valid suggestion", Experiment{Name: "c", Surface: SurfaceSuggestion, Variants: variants(Variant{Name: "x"}, Variant{Name: "y", Model: "gemini-test"})}, ""},
+		{"prompt on another surface", Experiment{Name: "g", Surface: SurfaceSuggestion, Variants: variants(Variant{Name: "x"}, Variant{Name: "y", PromptVersion: "test-v1"})}, "unknown prompt version"},
+		{"unknown prompt", Experiment{Name: "d", Surface: SurfaceFilter, Variants: variants(Variant{Name: "x"}, Variant{Name: "y", PromptVersion: "v99"})}, "unknown prompt version"},
+		{"unknown scorer", Experiment{Name: "e", Surface: SurfacePicker, Variants: variants(Variant{Name: "x"}, Variant{Name: "y", Scorer: "nope"})}, "nope"},
+		{"single variant", Experiment{Name: "f", Surface: SurfacePicker, Variants: variants(Variant{Name: "x"})}, "two variants"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewRegistry([]Experiment{tt.exp})
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want it to mention %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAssignIsStableAndWeighted(t *testing.T) {
+	registry, err := NewRegistry([]Experiment{{
+		Name:     "ranking",
+		Surface:  SurfacePicker,
+		Variants: variants(Variant{Name: "control", Weight: 3}, Variant{Name: "treatment", Weight: 1}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := registry.Assign(SurfacePicker, ""); ok {
+		t.Error("anonymous client was enrolled")
+	}
+	if _, ok := registry.Assign(SurfaceFilter, "client"); ok {
+		t.Error("client enrolled on a surface without experiments")
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		id := fmt.Sprintf("client-%d", i)
+		first, _ := registry.Assign(SurfacePicker, id)
+		again, _ := registry.Assign(SurfacePicker, id)
+		if first.Variant.Name != again.Variant.Name {
+			t.Fatalf("client %s moved from %s to %s", id, first.Variant.Name, again.Variant.Name)
+		}
+		counts[first.Variant.Name]++
+	}
+	if share := float64(counts["control"]) / 4000; share < 0.65 || share > 0.85 {
+		t.Errorf("control share = %.2f, want about 0.75", share)
+	}
+}
@@ -0,0 +1,151 @@
+package experiment
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outcome events
+const (
+	EventExposure = "exposure"
+	EventAccepted = "accepted"
+	EventRerolled = "rerolled"
+)
+
+// Outcome is one recorded event for a client in an experiment
+type Outcome struct {
+	Experiment     string    `json:"experiment"`
+	Variant        string    `json:"variant"`
+	ClientID       string    `json:"client_id"`
+	Event          string    `json:"event"`
+	RestaurantCode string    `json:"restaurant_code,omitempty"`
+	Time           time.Time `json:"time"`
+}
+
+// Store persists outcomes
+type Store interface {
+	Record(o Outcome) error
+	Outcomes(experiment string) ([]Outcome, error)
+}
+
+// DefaultMaxOutcomes bounds how many outcomes the default in-memory store
+// keeps per experiment
+const DefaultMaxOutcomes = 100000
+
+// DefaultStore is used by the handlers
+var DefaultStore Store = NewMemoryStore(DefaultMaxOutcomes)
+
+// MemoryStore keeps the most recent maxOutcomes outcomes of each experiment
+// for the life of the process
+type MemoryStore struct {
+	mu          sync.Mutex
+	maxOutcomes int
+	outcomes    map[string][]Outcome
+}
+
+// NewMemoryStore creates an empty in-memory store keeping up to maxOutcomes
+// per experiment, defaulting to DefaultMaxOutcomes
+func NewMemoryStore(maxOutcomes int) *MemoryStore {
+	if maxOutcomes <= 0 {
+		maxOutcomes = DefaultMaxOutcomes
+	}
+	return &MemoryStore{maxOutcomes: maxOutcomes, outcomes: make(map[string][]Outcome)}
+}
+
+func (s *MemoryStore) Record(o Outcome) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcomes := append(s.outcomes[o.Experiment], o)
+	// Trim in batches so old outcomes aren't copied on every record
+	if len(outcomes) >= 2*s.maxOutcomes {
+		outcomes = append([]Outcome(nil), outcomes[len(outcomes)-s.maxOutcomes:]...)
+	}
+	s.outcomes[o.Experiment] = outcomes
+	return nil
+}
+
+func (s *MemoryStore) Outcomes(experiment string) ([]Outcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcomes := s.outcomes[experiment]
+	if len(outcomes) > s.maxOutcomes {
+		outcomes = outcomes[len(outcomes)-s.maxOutcomes:]
+	}
+	return append([]Outcome(nil), outcomes...), nil
+}
+
+// FileStore appends outcomes to one JSON Lines file per experiment
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileStore creates a store in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create experiment store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(experiment string) string {
+	return filepath.Join(s.dir, filepath.Base(experiment)+".jsonl")
+}
+
+func (s *FileStore) Record(o Outcome) error {
+	line, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path(o.Experiment), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open experiment store: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write experiment store: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Outcomes(experiment string) ([]Outcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path(experiment))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open experiment store: %w", err)
+	}
+	defer f.Close()
+
+	var outcomes []Outcome
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var o Outcome
+		// Skip lines torn by a crash mid-write
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			continue
+		}
+		outcomes = append(outcomes, o)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read experiment store: %w", err)
+	}
+	return outcomes, nil
+}
@@ -0,0 +1,27 @@
+package experiment
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMemoryStoreKeepsMostRecent(t *testing.T) {
+	store := NewMemoryStore(3)
+	for i := 0; i < 10; i++ {
+		store.Record(Outcome{Experiment: "a", ClientID: fmt.Sprintf("client-%d", i), Event: EventExposure})
+	}
+	store.Record(Outcome{Experiment: "b", ClientID: "other", Event: EventAccepted})
+
+	outcomes, _ := store.Outcomes("a")
+	if len(outcomes) != 3 {
+		t.Fatalf("len(outcomes) = %d, want 3", len(outcomes))
+	}
+	for i, o := range outcomes {
+		if want := fmt.Sprintf("client-%d", 7+i); o.ClientID != want {
+			t.Errorf("outcomes[%d] = %s, want %s", i, o.ClientID, want)
+		}
+	}
+	if other, _ := store.Outcomes("b"); len(other) != 1 {
+		t.Errorf("experiment b has %d outcomes, want 1", len(other))
+	}
+}
@@ -0,0 +1,59 @@
+package experiment
+
+// VariantSummary aggregates the outcomes of one variant
+type VariantSummary struct {
+	Variant    string  `json:"variant"`
+	Clients    int     `json:"clients"`
+	Exposures  int     `json:"exposures"`
+	Accepted   int     `json:"accepted"`
+	Rerolled   int     `json:"rerolled"`
+	AcceptRate float64 `json:"accept_rate"`
+	RerollRate float64 `json:"reroll_rate"`
+}
+
+// Summary aggregates an experiment's outcomes per variant
+type Summary struct {
+	Experiment string           `json:"experiment"`
+	Surface    string           `json:"surface"`
+	Variants   []VariantSummary `json:"variants"`
+}
+
+// Summarize counts outcomes per variant. Rates are per exposure, and
+// variants no longer in the configuration are left out.
+func Summarize(e Experiment, outcomes []Outcome) Summary {
+	summary := Summary{Experiment: e.Name, Surface: e.Surface}
+	index := make(map[string]int)
+	clients := make([]map[string]bool, len(e.Variants))
+	for i, v := range e.Variants {
+		index[v.Name] = i
+		clients[i] = make(map[string]bool)
+		summary.Variants = append(summary.Variants, VariantSummary{Variant: v.Name})
+	}
+
+	for _, o := range outcomes {
+		i, ok := index[o.Variant]
+		if !ok {
+			continue
+		}
+		vs := &summary.Variants[i]
+		clients[i][o.ClientID] = true
+		switch o.Event {
+		case EventExposure:
+			vs.Exposures++
+		case EventAccepted:
+			vs.Accepted++
+		case EventRerolled:
+			vs.Rerolled++
+		}
+	}
+
+	for i := range summary.Variants {
+		vs := &summary.Variants[i]
+		vs.Clients = len(clients[i])
+		if vs.Exposures > 0 {
+			vs.AcceptRate = float64(vs.Accepted) / float64(vs.Exposures)
+			vs.RerollRate = float64(vs.Rerolled) / float64(vs.Exposures)
+		}
+	}
+	return summary
+}
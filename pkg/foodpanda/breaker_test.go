@@ -0,0 +1,73 @@
+package foodpanda
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := newBreaker(3, time.Hour)
+	for i := 0; i < 2; i++ {
+		if !b.allow() {
+			t.Fatal("breaker refused a call before reaching the threshold")
+		}
+		b.record(false)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("state = %s after 2 failures, want %s", b.State(), BreakerClosed)
+	}
+
+	// A success resets the count
+	b.allow()
+	b.record(true)
+	for i := 0; i < 3; i++ {
+		b.allow()
+		b.record(false)
+	}
+	if b.State() != BreakerOpen || b.allow() {
+		t.Errorf("state = %s after 3 failures, want an open breaker refusing calls", b.State())
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	b := newBreaker(1, 10*time.Millisecond)
+	b.allow()
+	b.record(false)
+	time.Sleep(20 * time.Millisecond)
+
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("state = %s after cooldown, want %s", b.State(), BreakerHalfOpen)
+	}
+	if !b.allow() {
+		t.Fatal("probe refused after cooldown")
+	}
+	if b.allow() {
+		t.Error("second call let through while the probe is in flight")
+	}
+
+	// A failed probe re-opens the breaker for another cooldown
+	b.record(false)
+	if b.State() != BreakerOpen || b.allow() {
+		t.Fatalf("state = %s after failed probe, want %s", b.State(), BreakerOpen)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	b.allow()
+	b.record(true)
+	if b.State() != BreakerClosed || !b.allow() {
+		t.Errorf("state = %s after successful probe, want %s", b.State(), BreakerClosed)
+	}
+}
+
+func TestBreakerReleaseFreesProbe(t *testing.T) {
+	b := newBreaker(1, 10*time.Millisecond)
+	b.allow()
+	b.record(false)
+	time.Sleep(20 * time.Millisecond)
+
+	b.allow()
+	b.release()
+	if !b.allow() {
+		t.Error("released probe was not handed to the next call")
+	}
+}
@@ -0,0 +1,186 @@
+package foodpanda
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"what-to-eat/pkg/structure"
+)
+
+// Cache statuses reported alongside cached responses
+const (
+	CacheMiss  = "miss"
+	CacheHit   = "hit"
+	CacheStale = "stale"
+)
+
+// listingCache is an LRU cache of vendor listings with TTL and
+// stale-while-revalidate. Cached responses are shared between callers and
+// must not be modified.
+type listingCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	staleTTL time.Duration
+	maxSize  int
+
+	entries    map[string]*list.Element
+	order      *list.List
+	refreshing map[string]bool
+}
+
+type cacheEntry struct {
+	key       string
+	value     *structure.FoodPandaRestaurantResponse
+	fetchedAt time.Time
+}
+
+func newListingCache(ttl, staleTTL time.Duration, maxSize int) *listingCache {
+	return &listingCache{
+		ttl:        ttl,
+		staleTTL:   staleTTL,
+		maxSize:    maxSize,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		refreshing: make(map[string]bool),
+	}
+}
+
+// get returns the cached value and its status. Entries older than ttl are
+// stale until ttl+staleTTL, after which they count as a miss.
+func (c *listingCache) get(key string) (*structure.FoodPandaRestaurantResponse, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, CacheMiss
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	age := time.Since(entry.fetchedAt)
+	switch {
+	case age < c.ttl:
+		c.order.MoveToFront(elem)
+		return entry.value, CacheHit
+	case age < c.ttl+c.staleTTL:
+		c.order.MoveToFront(elem)
+		return entry.value, CacheStale
+	default:
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, CacheMiss
+	}
+}
+
+func (c *listingCache) set(key string, value *structure.FoodPandaRestaurantResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.value = value
+		entry.fetchedAt = time.Now()
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, fetchedAt: time.Now()})
+	for c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// startRefresh marks key as being refreshed, returning false if a refresh
+// is already in flight
+func (c *listingCache) startRefresh(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.refreshing[key] {
+		return false
+	}
+	c.refreshing[key] = true
+	return true
+}
+
+func (c *listingCache) finishRefresh(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.refreshing, key)
+}
+
+// cacheKey buckets the location into a grid cell so nearby requests share
+// an entry
+func (c *Client) cacheKey(kind string, opts ListingOptions, maxItems int) string {
+	cell := c.config.CacheCellSize
+	latitude := math.Round(opts.Latitude/cell) * cell
+	longitude := math.Round(opts.Longitude/cell) * cell
+
+	cuisines := append([]string(nil), opts.Cuisines...)
+	sort.Strings(cuisines)
+
+	return fmt.Sprintf("%s|%s|%s|%s|%.5f,%.5f|%s|%d",
+		kind, opts.Country, opts.LanguageID, opts.Vertical,
+		latitude, longitude, strings.Join(cuisines, ","), maxItems)
+}
+
+// cached serves key from the cache, fetching on a miss and revalidating in
+// the background when the entry is stale
+func (c *Client) cached(ctx context.Context, key string, fetch func(ctx context.Context) (*structure.FoodPandaRestaurantResponse, error)) (*structure.FoodPandaRestaurantResponse, string, error) {
+	value, status := c.cache.get(key)
+	switch status {
+	case CacheHit:
+		return value, status, nil
+	case CacheStale:
+		if c.cache.startRefresh(key) {
+			go func() {
+				defer c.cache.finishRefresh(key)
+
+				ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+				defer cancel()
+				if fresh, err := fetch(ctx); err == nil {
+					c.cache.set(key, fresh)
+				}
+			}()
+		}
+		return value, status, nil
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		return nil, CacheMiss, err
+	}
+	c.cache.set(key, value)
+
+	return value, CacheMiss, nil
+}
+
+// CachedListAllVendors is ListAllVendors served from the location cache.
+// The returned response is shared and must not be modified.
+func (c *Client) CachedListAllVendors(ctx context.Context, opts ListingOptions, maxItems int) (*structure.FoodPandaRestaurantResponse, string, error) {
+	key := c.cacheKey("listing", opts, maxItems)
+	return c.cached(ctx, key, func(ctx context.Context) (*structure.FoodPandaRestaurantResponse, error) {
+		return c.ListAllVendors(ctx, opts, maxItems)
+	})
+}
+
+// CachedAggregations fetches the listing aggregations (such as cuisine
+// counts) through the location cache. The returned response is shared and
+// must not be modified.
+func (c *Client) CachedAggregations(ctx context.Context, opts ListingOptions) (*structure.FoodPandaRestaurantResponse, string, error) {
+	opts.Limit = 1
+	opts.Offset = 0
+	key := c.cacheKey("aggregations", opts, 0)
+	return c.cached(ctx, key, func(ctx context.Context) (*structure.FoodPandaRestaurantResponse, error) {
+		return c.ListVendors(ctx, opts)
+	})
+}
@@ -0,0 +1,104 @@
+package foodpanda
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"what-to-eat/pkg/structure"
+)
+
+func listing(count int) *structure.FoodPandaRestaurantResponse {
+	resp := &structure.FoodPandaRestaurantResponse{}
+	resp.Data.ReturnedCount = count
+	return resp
+}
+
+func TestListingCacheExpiry(t *testing.T) {
+	c := newListingCache(20*time.Millisecond, 20*time.Millisecond, 8)
+	c.set("k", listing(1))
+
+	if _, status := c.get("k"); status != CacheHit {
+		t.Errorf("fresh entry status = %s, want %s", status, CacheHit)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if value, status := c.get("k"); status != CacheStale || value == nil {
+		t.Errorf("aged entry status = %s, want %s with the old value", status, CacheStale)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if _, status := c.get("k"); status != CacheMiss {
+		t.Errorf("expired entry status = %s, want %s", status, CacheMiss)
+	}
+}
+
+func TestListingCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newListingCache(time.Hour, 0, 2)
+	c.set("a", listing(1))
+	c.set("b", listing(2))
+	c.get("a")
+	c.set("c", listing(3))
+
+	if _, status := c.get("b"); status != CacheMiss {
+		t.Error("least recently used entry was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, status := c.get(key); status != CacheHit {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+}
+
+func TestCachedRevalidatesStaleEntriesInBackground(t *testing.T) {
+	client := NewClient(structure.PerimeterConfig{CacheTTL: 10 * time.Millisecond, CacheStaleTTL: time.Hour})
+	var calls atomic.Int32
+	fetch := func(ctx context.Context) (*structure.FoodPandaRestaurantResponse, error) {
+		return listing(int(calls.Add(1))), nil
+	}
+	ctx := context.Background()
+
+	if value, status, _ := client.cached(ctx, "k", fetch); status != CacheMiss || value.Data.ReturnedCount != 1 {
+		t.Fatalf("first call = %s, want a miss that fetches", status)
+	}
+	if _, status, _ := client.cached(ctx, "k", fetch); status != CacheHit {
+		t.Errorf("second call = %s, want %s", status, CacheHit)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	value, status, _ := client.cached(ctx, "k", fetch)
+	if status != CacheStale || value.Data.ReturnedCount != 1 {
+		t.Errorf("stale call = %s with value %d, want the old value", status, value.Data.ReturnedCount)
+	}
+
+	// The refresh runs in the background; wait for it to land
+	deadline := time.Now().Add(time.Second)
+	for {
+		value, _ := client.cache.get("k")
+		if value != nil && value.Data.ReturnedCount == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stale entry was never refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("fetched %d times, want 2", n)
+	}
+}
+
+func TestCachedDoesNotStoreErrors(t *testing.T) {
+	client := NewClient(structure.PerimeterConfig{})
+	failure := errors.New("upstream down")
+	fetch := func(ctx context.Context) (*structure.FoodPandaRestaurantResponse, error) {
+		return nil, failure
+	}
+
+	if _, _, err := client.cached(context.Background(), "k", fetch); !errors.Is(err, failure) {
+		t.Errorf("error = %v, want %v", err, failure)
+	}
+	if _, status := client.cache.get("k"); status != CacheMiss {
+		t.Error("a failed fetch was cached")
+	}
+}
@@ -0,0 +1,247 @@
+package foodpanda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/structure"
+)
+
+// DefaultBaseURL is the Delivery Hero discovery API used for vendor listings
+const DefaultBaseURL = "https://disco.deliveryhero.io/listing/api/v1/pandora"
+
+// DefaultMenuBaseURL is the per-country vendor API used for menus, with the
+// country code substituted for %s
+const DefaultMenuBaseURL = "https://%s.fd-api.com/api/v5"
+
+// DefaultConfig returns the perimeter config used when none is supplied
+func DefaultConfig() structure.PerimeterConfig {
+	return structure.PerimeterConfig{
+		Timeout:     25 * time.Second,
+		BaseURL:     DefaultBaseURL,
+		MenuBaseURL: DefaultMenuBaseURL,
+		MaxRetries:  2,
+		Debug:       false,
+
+		PageSize:        100,
+		MaxConcurrency:  4,
+		MaxListingItems: 2000,
+
+		CacheTTL:      5 * time.Minute,
+		CacheStaleTTL: 10 * time.Minute,
+		CacheSize:     256,
+		CacheCellSize: 0.002,
+	}
+}
+
+// upstreamName labels errors raised by this client
+const upstreamName = "foodpanda"
+
+// Retry and circuit breaker tuning
+const (
+	backoffBase      = 200 * time.Millisecond
+	backoffMax       = 5 * time.Second
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// Client talks to the Foodpanda discovery API. Menu calls have their own
+// breaker so a failing menu batch cannot take the listing endpoints down.
+type Client struct {
+	config      structure.PerimeterConfig
+	httpClient  *http.Client
+	breaker     *breaker
+	menuBreaker *breaker
+	cache       *listingCache
+}
+
+// DefaultClient is shared by the handlers
+var DefaultClient = NewClient(DefaultConfig())
+
+// NewClient creates a client from the given perimeter config, filling in
+// defaults for zero values
+func NewClient(config structure.PerimeterConfig) *Client {
+	defaults := DefaultConfig()
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = defaults.BaseURL
+	}
+	if config.MenuBaseURL == "" {
+		config.MenuBaseURL = defaults.MenuBaseURL
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = defaults.PageSize
+	}
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = defaults.MaxConcurrency
+	}
+	if config.MaxListingItems <= 0 {
+		config.MaxListingItems = defaults.MaxListingItems
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaults.CacheTTL
+	}
+	if config.CacheStaleTTL < 0 {
+		config.CacheStaleTTL = 0
+	}
+	if config.CacheSize <= 0 {
+		config.CacheSize = defaults.CacheSize
+	}
+	if config.CacheCellSize <= 0 {
+		config.CacheCellSize = defaults.CacheCellSize
+	}
+
+	return &Client{
+		config: config,
+		httpClient: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: config.Transport,
+		},
+		breaker:     newBreaker(breakerThreshold, breakerCooldown),
+		menuBreaker: newBreaker(breakerThreshold, breakerCooldown),
+		cache:       newListingCache(config.CacheTTL, config.CacheStaleTTL, config.CacheSize),
+	}
+}
+
+// Config returns the perimeter config the client was created with
+func (c *Client) Config() structure.PerimeterConfig {
+	return c.config
+}
+
+// BreakerState reports the listing circuit breaker state for health checks
+func (c *Client) BreakerState() string {
+	return c.breaker.State()
+}
+
+// MenuBreakerState reports the menu circuit breaker state for health checks
+func (c *Client) MenuBreakerState() string {
+	return c.menuBreaker.State()
+}
+
+// getJSON performs an idempotent GET request against baseURL, guarded by b,
+// and decodes the body into out. Transient failures are retried up to
+// MaxRetries times with jittered exponential backoff.
+func (c *Client) getJSON(ctx context.Context, b *breaker, baseURL, path string, query url.Values, out interface{}) error {
+	endpoint := baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	if !b.allow() {
+		return apierror.New(apierror.KindUnavailable, upstreamName, ErrCircuitOpen)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepBackoff(ctx, attempt); err != nil {
+				break
+			}
+		}
+
+		if c.config.Debug {
+			log.Printf("foodpanda: GET %s (attempt %d)", endpoint, attempt+1)
+		}
+
+		lastErr = c.doGet(ctx, endpoint, out)
+		if lastErr == nil || !isTransient(lastErr) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	switch {
+	case lastErr == nil:
+		b.record(true)
+	case ctx.Err() != nil:
+		// Our caller gave up, which says nothing about Foodpanda's health
+		b.release()
+	case countsAsFailure(lastErr):
+		b.record(false)
+	default:
+		// A well-formed refusal such as a 404 shows Foodpanda is up, but
+		// only a 2xx that decodes counts as a success
+		b.release()
+	}
+
+	return lastErr
+}
+
+// sleepBackoff waits a random duration up to backoffBase * 2^(attempt-1),
+// capped at backoffMax
+func sleepBackoff(ctx context.Context, attempt int) error {
+	ceiling := backoffBase << (attempt - 1)
+	if ceiling <= 0 || ceiling > backoffMax {
+		ceiling = backoffMax
+	}
+	delay := time.Duration(rand.Int63n(int64(ceiling)))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isTransient reports whether a failed request may succeed if repeated
+func isTransient(err error) bool {
+	var upstreamErr *apierror.Error
+	return errors.As(err, &upstreamErr) && upstreamErr.Retryable()
+}
+
+// countsAsFailure reports whether err counts against the circuit breaker:
+// transient failures, and responses showing we are blocked or that
+// Foodpanda is serving something other than its API
+func countsAsFailure(err error) bool {
+	var upstreamErr *apierror.Error
+	if !errors.As(err, &upstreamErr) {
+		return false
+	}
+	return upstreamErr.Retryable() || upstreamErr.Kind == apierror.KindBlocked || upstreamErr.Kind == apierror.KindMalformed
+}
+
+func (c *Client) doGet(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Add("x-disco-client-id", "web")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return apierror.FromTransport(upstreamName, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return apierror.FromTransport(upstreamName, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return apierror.FromStatus(upstreamName, resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return apierror.Malformed(upstreamName, err)
+	}
+
+	return nil
+}
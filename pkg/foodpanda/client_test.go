@@ -0,0 +1,74 @@
+package foodpanda
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"what-to-eat/pkg/structure"
+)
+
+func TestMenuFailuresDoNotOpenListingBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/vendors/") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data": {"items": []}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(structure.PerimeterConfig{
+		BaseURL:     server.URL,
+		MenuBaseURL: server.URL + "/%s",
+	})
+	ctx := context.Background()
+
+	for i := 0; i < breakerThreshold; i++ {
+		if _, err := client.VendorMenu(ctx, "abcd", MenuOptions{Country: "tw"}); err == nil {
+			t.Fatal("expected the menu call to fail")
+		}
+	}
+	if got := client.MenuBreakerState(); got != BreakerOpen {
+		t.Errorf("menu breaker = %s, want %s", got, BreakerOpen)
+	}
+	if got := client.BreakerState(); got != BreakerClosed {
+		t.Errorf("listing breaker = %s, want %s", got, BreakerClosed)
+	}
+	if _, err := client.ListVendors(ctx, ListingOptions{Latitude: 25.04, Longitude: 121.56}); err != nil {
+		t.Errorf("listing failed after menu errors: %v", err)
+	}
+}
+
+func TestBreakerCountsBlockedAndMalformedResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"blocked", http.StatusForbidden, `<html>captcha</html>`, BreakerOpen},
+		{"malformed", http.StatusOK, `<html>captcha</html>`, BreakerOpen},
+		{"not found", http.StatusNotFound, `{}`, BreakerClosed},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		client := NewClient(structure.PerimeterConfig{BaseURL: server.URL})
+
+		for i := 0; i < breakerThreshold; i++ {
+			if _, err := client.ListVendors(context.Background(), ListingOptions{Latitude: 25.04, Longitude: 121.56}); err == nil {
+				t.Fatalf("%s: expected the listing call to fail", tt.name)
+			}
+		}
+		if got := client.BreakerState(); got != tt.want {
+			t.Errorf("%s: breaker = %s after %d responses, want %s", tt.name, got, breakerThreshold, tt.want)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,184 @@
+package foodpanda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"what-to-eat/pkg/structure"
+)
+
+// ListingOptions describes a vendor listing query
+type ListingOptions struct {
+	Latitude   float64
+	Longitude  float64
+	Cuisines   []string
+	Limit      int
+	Offset     int
+	Vertical   string
+	Country    string
+	LanguageID string
+}
+
+// query converts the options into the disco API query parameters
+func (o ListingOptions) query() url.Values {
+	vertical := o.Vertical
+	if vertical == "" {
+		vertical = "restaurants"
+	}
+	country := o.Country
+	if country == "" {
+		country = DefaultCountry
+	}
+	languageID := o.LanguageID
+	if languageID == "" {
+		languageID = "6"
+	}
+	limit := o.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	q := url.Values{}
+	q.Add("country", country)
+	q.Add("latitude", fmt.Sprintf("%f", o.Latitude))
+	q.Add("longitude", fmt.Sprintf("%f", o.Longitude))
+	q.Add("language_id", languageID)
+	q.Add("include", "characteristics")
+	q.Add("dynamic_pricing", "0")
+	q.Add("configuration", "Original")
+	q.Add("vertical", vertical)
+	q.Add("limit", strconv.Itoa(limit))
+	q.Add("offset", strconv.Itoa(o.Offset))
+	q.Add("customer_type", "regular")
+
+	if len(o.Cuisines) > 0 {
+		q.Add("cuisine", strings.Join(o.Cuisines, ","))
+	}
+
+	return q
+}
+
+// ListVendors fetches a single page of vendors around the given location
+func (c *Client) ListVendors(ctx context.Context, opts ListingOptions) (*structure.FoodPandaRestaurantResponse, error) {
+	var resp structure.FoodPandaRestaurantResponse
+	if err := c.getJSON(ctx, c.breaker, c.config.BaseURL, "/vendors", opts.query(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListAllVendors fetches the complete vendor listing in pages of
+// PageSize, requesting up to MaxConcurrency pages at once. At most maxItems
+// vendors are returned; a non-positive maxItems uses MaxListingItems from
+// the config.
+func (c *Client) ListAllVendors(ctx context.Context, opts ListingOptions, maxItems int) (*structure.FoodPandaRestaurantResponse, error) {
+	if maxItems <= 0 || maxItems > c.config.MaxListingItems {
+		maxItems = c.config.MaxListingItems
+	}
+	pageSize := c.config.PageSize
+	if pageSize > maxItems {
+		pageSize = maxItems
+	}
+
+	// The first page tells us how many vendors are available
+	first := opts
+	first.Limit = pageSize
+	first.Offset = 0
+	resp, err := c.ListVendors(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+
+	total := resp.Data.AvailableCount
+	if total > maxItems {
+		total = maxItems
+	}
+	if len(resp.Data.Items) < pageSize || total <= pageSize {
+		resp.Data.Items = truncateItems(resp.Data.Items, maxItems)
+		resp.Data.ReturnedCount = len(resp.Data.Items)
+		return resp, nil
+	}
+
+	// Fetch the remaining pages concurrently
+	var offsets []int
+	for offset := pageSize; offset < total; offset += pageSize {
+		offsets = append(offsets, offset)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make([][]structure.RestaurantItem, len(offsets))
+	errs := make([]error, len(offsets))
+	sem := make(chan struct{}, c.config.MaxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, offset := range offsets {
+		wg.Add(1)
+		go func(i, offset int) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+
+			page := opts
+			page.Limit = pageSize
+			page.Offset = offset
+			pageResp, err := c.ListVendors(ctx, page)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to fetch page at offset %d: %w", offset, err)
+				cancel()
+				return
+			}
+			pages[i] = pageResp.Data.Items
+		}(i, offset)
+	}
+	wg.Wait()
+
+	// Report the error that triggered cancellation rather than the
+	// cancellations it caused
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Merge pages in order, skipping vendors that shifted between pages
+	seen := make(map[int]bool, total)
+	items := make([]structure.RestaurantItem, 0, total)
+	for _, page := range append([][]structure.RestaurantItem{resp.Data.Items}, pages...) {
+		for _, item := range page {
+			if seen[item.ID] {
+				continue
+			}
+			seen[item.ID] = true
+			items = append(items, item)
+		}
+	}
+
+	resp.Data.Items = truncateItems(items, maxItems)
+	resp.Data.ReturnedCount = len(resp.Data.Items)
+
+	return resp, nil
+}
+
+func truncateItems(items []structure.RestaurantItem, max int) []structure.RestaurantItem {
+	if len(items) > max {
+		return items[:max]
+	}
+	return items
+}
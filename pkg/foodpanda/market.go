@@ -0,0 +1,151 @@
+package foodpanda
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"what-to-eat/pkg/structure"
+)
+
+// DefaultCountry is used by lower-level calls that are given no country
+const DefaultCountry = "tw"
+
+// boundingBox is a rough rectangle around a market's service area
+type boundingBox struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+func (b boundingBox) contains(latitude, longitude float64) bool {
+	return latitude >= b.MinLatitude && latitude <= b.MaxLatitude &&
+		longitude >= b.MinLongitude && longitude <= b.MaxLongitude
+}
+
+// marketInfo describes a supported market
+type marketInfo struct {
+	Country         string
+	DefaultLanguage string
+	UTCOffset       time.Duration
+	Bounds          []boundingBox
+}
+
+// markets is checked in order, so smaller markets that sit inside a larger
+// neighbour's box must come first. Boxes follow borders closely enough that
+// major cities of unsupported neighbours (Ho Chi Minh City, New Delhi,
+// Kolkata) fall outside every market.
+var markets = []marketInfo{
+	{Country: "hk", DefaultLanguage: "zh", UTCOffset: 8 * time.Hour, Bounds: []boundingBox{{22.15, 22.57, 113.83, 114.44}}},
+	// Singapore's north shore is a separate strip east of Johor Bahru, which
+	// belongs to the Malaysian market
+	{Country: "sg", DefaultLanguage: "en", UTCOffset: 8 * time.Hour, Bounds: []boundingBox{{1.15, 1.43, 103.6, 104.1}, {1.43, 1.46, 103.77, 103.99}}},
+	{Country: "tw", DefaultLanguage: "zh", UTCOffset: 8 * time.Hour, Bounds: []boundingBox{{21.8, 26.4, 118.0, 122.1}}},
+	// Cambodia's boxes stop short of Thailand's Trat and Sa Kaeo border
+	// provinces, which sit east of the Thai box's western edge, and of
+	// southern Vietnam
+	{Country: "kh", DefaultLanguage: "en", UTCOffset: 7 * time.Hour, Bounds: []boundingBox{
+		{10.4, 11.65, 102.3, 105.0}, {10.95, 11.65, 105.0, 106.15},
+		{11.65, 12.3, 102.95, 106.45}, {12.3, 14.4, 102.95, 107.6},
+	}},
+	{Country: "my", DefaultLanguage: "en", UTCOffset: 8 * time.Hour, Bounds: []boundingBox{{1.2, 6.8, 99.6, 104.5}, {0.85, 7.4, 109.5, 119.3}}},
+	{Country: "ph", DefaultLanguage: "en", UTCOffset: 8 * time.Hour, Bounds: []boundingBox{{4.5, 21.2, 116.9, 126.7}}},
+	{Country: "th", DefaultLanguage: "en", UTCOffset: 7 * time.Hour, Bounds: []boundingBox{{5.6, 20.5, 97.3, 105.7}}},
+	{Country: "bd", DefaultLanguage: "en", UTCOffset: 6 * time.Hour, Bounds: []boundingBox{
+		{20.6, 24.0, 88.95, 92.7}, {24.0, 25.3, 88.2, 92.5}, {25.3, 26.7, 88.5, 89.9},
+	}},
+	{Country: "pk", DefaultLanguage: "en", UTCOffset: 5 * time.Hour, Bounds: []boundingBox{
+		{23.6, 28.0, 60.8, 71.0}, {28.0, 29.0, 60.8, 71.9}, {29.0, 30.0, 60.8, 73.4}, {30.0, 37.1, 60.8, 74.6},
+	}},
+}
+
+// languageIDs maps language codes to the disco API language_id values
+var languageIDs = map[string]string{
+	"en": "1",
+	"zh": "6",
+}
+
+// Languages returns the supported language codes
+func Languages() []string {
+	languages := make([]string, 0, len(languageIDs))
+	for language := range languageIDs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func findMarket(country string) (marketInfo, bool) {
+	for _, m := range markets {
+		if m.Country == country {
+			return m, true
+		}
+	}
+	return marketInfo{}, false
+}
+
+// Location returns the market's local time zone. None of the supported
+// markets observe daylight saving, so a fixed offset is enough.
+func Location(country string) *time.Location {
+	info, ok := findMarket(country)
+	if !ok {
+		info, _ = findMarket(DefaultCountry)
+	}
+	return time.FixedZone(strings.ToUpper(info.Country), int(info.UTCOffset.Seconds()))
+}
+
+// MarketForLocation returns the country whose bounding box contains the
+// coordinates, or false if none does
+func MarketForLocation(latitude, longitude float64) (string, bool) {
+	for _, m := range markets {
+		for _, b := range m.Bounds {
+			if b.contains(latitude, longitude) {
+				return m.Country, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ResolveMarket determines the country and language for a request. Explicit
+// values win; otherwise the country is derived from the coordinates, which
+// must lie in a supported market, and the language falls back to the market
+// default.
+func ResolveMarket(country, language string, latitude, longitude float64) (structure.Market, error) {
+	country = strings.ToLower(strings.TrimSpace(country))
+	language = strings.ToLower(strings.TrimSpace(language))
+
+	if country == "" {
+		found, ok := MarketForLocation(latitude, longitude)
+		if !ok {
+			return structure.Market{}, fmt.Errorf("no supported market at %f,%f", latitude, longitude)
+		}
+		country = found
+	}
+
+	info, ok := findMarket(country)
+	if !ok {
+		return structure.Market{}, fmt.Errorf("unsupported country: %s", country)
+	}
+
+	if language == "" {
+		language = info.DefaultLanguage
+	}
+	// Accept locale tags such as zh-TW or en_US
+	if i := strings.IndexAny(language, "-_"); i > 0 {
+		language = language[:i]
+	}
+
+	languageID, ok := languageIDs[language]
+	if !ok {
+		return structure.Market{}, fmt.Errorf("unsupported language: %s", language)
+	}
+
+	return structure.Market{
+		Country:    country,
+		Language:   language,
+		LanguageID: languageID,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package foodpanda
+
+import "testing"
+
+func TestMarketForLocation(t *testing.T) {
+	tests := []struct {
+		place     string
+		latitude  float64
+		longitude float64
+		want      string
+	}{
+		{"Taipei", 25.04, 121.56, "tw"},
+		{"Hong Kong", 22.28, 114.16, "hk"},
+		{"Singapore", 1.29, 103.85, "sg"},
+		{"Kuala Lumpur", 3.14, 101.69, "my"},
+		{"Manila", 14.60, 120.98, "ph"},
+		{"Bangkok", 13.76, 100.50, "th"},
+		{"Trat", 12.24, 102.51, "th"},
+		{"Aranyaprathet", 13.69, 102.50, "th"},
+		{"Phnom Penh", 11.55, 104.92, "kh"},
+		{"Siem Reap", 13.36, 103.86, "kh"},
+		{"Koh Kong", 11.61, 102.98, "kh"},
+		{"Johor Bahru", 1.46, 103.76, "my"},
+		{"Woodlands", 1.436, 103.786, "sg"},
+		{"Changi", 1.36, 103.99, "sg"},
+		{"Svay Rieng", 11.09, 105.80, "kh"},
+		{"Dhaka", 23.81, 90.41, "bd"},
+		{"Khulna", 22.82, 89.55, "bd"},
+		{"Rajshahi", 24.37, 88.60, "bd"},
+		{"Rangpur", 25.74, 89.25, "bd"},
+		{"Karachi", 24.86, 67.01, "pk"},
+		{"Lahore", 31.55, 74.34, "pk"},
+		{"Bahawalpur", 29.40, 71.68, "pk"},
+		{"Islamabad", 33.69, 73.05, "pk"},
+	}
+
+	for _, tt := range tests {
+		got, ok := MarketForLocation(tt.latitude, tt.longitude)
+		if !ok || got != tt.want {
+			t.Errorf("%s: MarketForLocation = %q, %v; want %q", tt.place, got, ok, tt.want)
+		}
+	}
+
+	unsupported := []struct {
+		place     string
+		latitude  float64
+		longitude float64
+	}{
+		{"Paris", 48.85, 2.35},
+		{"Ho Chi Minh City", 10.78, 106.70},
+		{"New Delhi", 28.61, 77.21},
+		{"Amritsar", 31.63, 74.87},
+		{"Kolkata", 22.57, 88.36},
+		{"Guwahati", 26.14, 91.74},
+	}
+	for _, tt := range unsupported {
+		if got, ok := MarketForLocation(tt.latitude, tt.longitude); ok {
+			t.Errorf("%s resolved to %q, want no market", tt.place, got)
+		}
+		if _, err := ResolveMarket("", "", tt.latitude, tt.longitude); err == nil {
+			t.Errorf("%s: ResolveMarket accepted an unsupported location", tt.place)
+		}
+	}
+}
+
+func TestResolveMarketNormalizesCountry(t *testing.T) {
+	market, err := ResolveMarket("TW", "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if market.Country != "tw" || market.Language != "zh" {
+		t.Errorf("ResolveMarket = %+v, want tw/zh", market)
+	}
+}
@@ -0,0 +1,45 @@
+package foodpanda
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"what-to-eat/pkg/structure"
+)
+
+// MenuOptions describes a vendor menu query
+type MenuOptions struct {
+	Latitude   float64
+	Longitude  float64
+	Country    string
+	LanguageID string
+}
+
+// VendorMenu fetches the full menu of a single vendor
+func (c *Client) VendorMenu(ctx context.Context, code string, opts MenuOptions) (*structure.FoodPandaMenuResponse, error) {
+	if code == "" {
+		return nil, fmt.Errorf("vendor code is required")
+	}
+
+	country := opts.Country
+	if country == "" {
+		country = DefaultCountry
+	}
+
+	q := url.Values{}
+	q.Add("include", "menus")
+	q.Add("latitude", fmt.Sprintf("%f", opts.Latitude))
+	q.Add("longitude", fmt.Sprintf("%f", opts.Longitude))
+	if opts.LanguageID != "" {
+		q.Add("language_id", opts.LanguageID)
+	}
+
+	baseURL := fmt.Sprintf(c.config.MenuBaseURL, country)
+	var resp structure.FoodPandaMenuResponse
+	if err := c.getJSON(ctx, c.menuBreaker, baseURL, "/vendors/"+url.PathEscape(code), q, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
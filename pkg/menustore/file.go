@@ -0,0 +1,154 @@
+package menustore
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore keeps one JSON file per menu in a directory so menus survive
+// restarts and can be shared between instances on the same disk. Like
+// MemoryStore, Set periodically sweeps out expired entries.
+type FileStore struct {
+	mu        sync.Mutex
+	dir       string
+	lastSweep time.Time
+}
+
+type fileEntry struct {
+	Key       string          `json:"key"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewFileStore creates a store in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create menu store directory: %w", err)
+	}
+	return &FileStore{dir: dir, lastSweep: time.Now()}, nil
+}
+
+// path hashes the key so arbitrary vendor codes map to safe file names
+func (s *FileStore) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *FileStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read menu store entry: %w", err)
+	}
+
+	var entry fileEntry
+	if err := json.Unmarshal(raw, &entry); err != nil || entry.Key != key {
+		// Treat corrupt or colliding entries as missing
+		os.Remove(s.path(key))
+		return nil, false, nil
+	}
+	if time.Now().After(entry.ExpiresAt) {
+		os.Remove(s.path(key))
+		return nil, false, nil
+	}
+
+	return entry.Data, true, nil
+}
+
+func (s *FileStore) Set(key string, data []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("menu store data must be valid JSON")
+	}
+
+	raw, err := json.Marshal(fileEntry{
+		Key:       key,
+		ExpiresAt: time.Now().Add(ttl),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweepLocked(now)
+	}
+
+	// Write to a temp file and rename so readers never see partial entries
+	tmp, err := os.CreateTemp(s.dir, "menu-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create menu store entry: %w", err)
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write menu store entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write menu store entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write menu store entry: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileStore) Invalidate(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove menu store entry: %w", err)
+	}
+	return nil
+}
+
+// sweepLocked removes every entry that has expired by now or can't be read,
+// and temp files left behind by writes that never finished
+func (s *FileStore) sweepLocked(now time.Time) {
+	s.lastSweep = now
+
+	names, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		path := filepath.Join(s.dir, name.Name())
+		switch filepath.Ext(path) {
+		case ".tmp":
+			if info, err := name.Info(); err == nil && now.Sub(info.ModTime()) >= sweepInterval {
+				os.Remove(path)
+			}
+		case ".json":
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			var entry fileEntry
+			if err := json.Unmarshal(raw, &entry); err != nil || now.After(entry.ExpiresAt) {
+				os.Remove(path)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package menustore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestFileStore(t *testing.T) *FileStore {
+	t.Helper()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestFileStoreExpiresEntries(t *testing.T) {
+	store := newTestFileStore(t)
+	if err := store.Set("menu", []byte(`{"a":1}`), time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, ok, _ := store.Get("menu"); ok {
+		t.Error("expired entry was returned")
+	}
+	if n := countFiles(t, store.dir); n != 0 {
+		t.Errorf("%d files left after reading an expired entry, want 0", n)
+	}
+}
+
+func TestFileStoreInvalidate(t *testing.T) {
+	store := newTestFileStore(t)
+	store.Set("menu", []byte(`{"a":1}`), time.Hour)
+	store.Set("other", []byte(`{"b":2}`), time.Hour)
+
+	if err := store.Invalidate("menu"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Invalidate("missing"); err != nil {
+		t.Errorf("invalidating a missing key: %v", err)
+	}
+	if _, ok, _ := store.Get("menu"); ok {
+		t.Error("invalidated entry was returned")
+	}
+	if data, ok, _ := store.Get("other"); !ok || string(data) != `{"b":2}` {
+		t.Errorf("other = %s, %v; want it untouched", data, ok)
+	}
+}
+
+func TestFileStoreTreatsCorruptEntriesAsMissing(t *testing.T) {
+	store := newTestFileStore(t)
+	if err := os.WriteFile(store.path("menu"), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, err := store.Get("menu"); ok || err != nil {
+		t.Errorf("Get = %v, %v; want a miss without error", ok, err)
+	}
+	if _, err := os.Stat(store.path("menu")); !os.IsNotExist(err) {
+		t.Error("corrupt entry was not removed")
+	}
+	if err := store.Set("menu", []byte("not json"), time.Hour); err == nil {
+		t.Error("Set accepted data that isn't JSON")
+	}
+}
+
+func TestFileStoreSweepsExpiredEntries(t *testing.T) {
+	store := newTestFileStore(t)
+	store.Set("expired", []byte(`"old"`), time.Nanosecond)
+	os.WriteFile(filepath.Join(store.dir, "corrupt.json"), []byte("{"), 0o644)
+	abandoned := filepath.Join(store.dir, "menu-1.tmp")
+	os.WriteFile(abandoned, []byte("{"), 0o644)
+	old := time.Now().Add(-sweepInterval)
+	os.Chtimes(abandoned, old, old)
+	time.Sleep(time.Millisecond)
+
+	// Backdate the last sweep so the next Set sweeps
+	store.lastSweep = time.Now().Add(-sweepInterval)
+	store.Set("fresh", []byte(`"new"`), time.Hour)
+
+	if n := countFiles(t, store.dir); n != 1 {
+		t.Errorf("%d files after the sweep, want only the fresh entry", n)
+	}
+	if _, ok, _ := store.Get("fresh"); !ok {
+		t.Error("fresh entry was swept")
+	}
+}
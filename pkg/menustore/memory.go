@@ -0,0 +1,127 @@
+package menustore
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultMemoryBytes bounds the default in-memory store
+const DefaultMemoryBytes = 64 << 20
+
+// sweepInterval is how often Set drops every expired entry, not just the
+// ones being read
+const sweepInterval = 10 * time.Minute
+
+// MemoryStore keeps menus in process memory. Once the stored data exceeds
+// maxBytes the least recently used entries are evicted.
+type MemoryStore struct {
+	mu        sync.Mutex
+	maxBytes  int
+	size      int
+	entries   map[string]*list.Element
+	order     *list.List
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store holding up to maxBytes of
+// data, defaulting to DefaultMemoryBytes
+func NewMemoryStore(maxBytes int) *MemoryStore {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMemoryBytes
+	}
+	return &MemoryStore{
+		maxBytes:  maxBytes,
+		entries:   make(map[string]*list.Element),
+		order:     list.New(),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		s.removeLocked(elem)
+		return nil, false, nil
+	}
+
+	s.order.MoveToFront(elem)
+	return entry.data, true, nil
+}
+
+func (s *MemoryStore) Set(key string, data []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweepLocked(now)
+	}
+
+	if elem, ok := s.entries[key]; ok {
+		s.removeLocked(elem)
+	}
+	// An entry that could never fit would only flush everything else
+	if len(data) > s.maxBytes {
+		return nil
+	}
+
+	entry := &memoryEntry{
+		key:       key,
+		data:      append([]byte(nil), data...),
+		expiresAt: now.Add(ttl),
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	s.size += len(entry.data)
+
+	for s.size > s.maxBytes {
+		s.removeLocked(s.order.Back())
+	}
+	return nil
+}
+
+func (s *MemoryStore) Invalidate(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.removeLocked(elem)
+	}
+	return nil
+}
+
+// sweepLocked drops every entry that has expired by now
+func (s *MemoryStore) sweepLocked(now time.Time) {
+	for elem := s.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if now.After(elem.Value.(*memoryEntry).expiresAt) {
+			s.removeLocked(elem)
+		}
+		elem = prev
+	}
+	s.lastSweep = now
+}
+
+func (s *MemoryStore) removeLocked(elem *list.Element) {
+	entry := elem.Value.(*memoryEntry)
+	s.order.Remove(elem)
+	delete(s.entries, entry.key)
+	s.size -= len(entry.data)
+}
@@ -0,0 +1,71 @@
+package menustore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewMemoryStore(10)
+	store.Set("a", []byte("1234"), time.Hour)
+	store.Set("b", []byte("1234"), time.Hour)
+	store.Get("a")
+	store.Set("c", []byte("1234"), time.Hour)
+
+	if _, ok, _ := store.Get("b"); ok {
+		t.Error("b should have been evicted as least recently used")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok, _ := store.Get(key); !ok {
+			t.Errorf("%s should still be stored", key)
+		}
+	}
+
+	store.Set("huge", make([]byte, 11), time.Hour)
+	if _, ok, _ := store.Get("huge"); ok {
+		t.Error("an entry larger than the store should not be kept")
+	}
+	if _, ok, _ := store.Get("a"); !ok {
+		t.Error("an oversized entry should not flush the rest")
+	}
+}
+
+func TestMemoryStoreSweepsExpiredEntries(t *testing.T) {
+	store := NewMemoryStore(0)
+	store.Set("expired", []byte("old"), time.Nanosecond)
+	store.Set("fresh", []byte("new"), time.Hour)
+	time.Sleep(time.Millisecond)
+
+	// Backdate the last sweep so the next Set sweeps
+	store.lastSweep = time.Now().Add(-sweepInterval)
+	store.Set("other", []byte("x"), time.Hour)
+
+	if _, ok := store.entries["expired"]; ok {
+		t.Error("expired entry survived the sweep")
+	}
+	if _, ok := store.entries["fresh"]; !ok {
+		t.Error("fresh entry was swept")
+	}
+	if store.size != len("new")+len("x") {
+		t.Errorf("size = %d, want %d", store.size, len("new")+len("x"))
+	}
+}
+
+func TestMemoryStoreReplaceAndInvalidate(t *testing.T) {
+	store := NewMemoryStore(0)
+	store.Set("k", []byte("first"), time.Hour)
+	store.Set("k", []byte("second"), time.Hour)
+
+	data, ok, _ := store.Get("k")
+	if !ok || string(data) != "second" {
+		t.Fatalf("Get = %q, %v; want second", data, ok)
+	}
+	if store.size != len("second") {
+		t.Errorf("size = %d after replace, want %d", store.size, len("second"))
+	}
+
+	store.Invalidate("k")
+	if _, ok, _ := store.Get("k"); ok || store.size != 0 {
+		t.Errorf("invalidated entry still stored (size %d)", store.size)
+	}
+}
@@ -0,0 +1,50 @@
+package menustore
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// DefaultTTL matches the expiry used by the puppeteer menu cache
+const DefaultTTL = 24 * time.Hour
+
+// MenuStore caches serialized menus by key
+type MenuStore interface {
+	// Get returns the stored data, or false if the key is missing or expired
+	Get(key string) ([]byte, bool, error)
+	// Set stores data under key for ttl
+	Set(key string, data []byte, ttl time.Duration) error
+	// Invalidate removes key from the store
+	Invalidate(key string) error
+}
+
+// Default is the store shared by the menu endpoint and the suggestion flow
+var Default MenuStore = NewMemoryStore(DefaultMemoryBytes)
+
+// Key builds a store key from a namespace, country, language and vendor
+// code. Menus are stored per language since Foodpanda translates them.
+func Key(namespace, country, language, code string) string {
+	return strings.Join([]string{namespace, country, language, code}, ":")
+}
+
+// GetJSON decodes the stored value for key into out
+func GetJSON(store MenuStore, key string, out interface{}) (bool, error) {
+	data, ok, err := store.Get(key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetJSON encodes value and stores it under key
+func SetJSON(store MenuStore, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return store.Set(key, data, ttl)
+}
@@ -0,0 +1,343 @@
+package poll
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"what-to-eat/pkg/structure"
+)
+
+// Errors returned by room operations
+var (
+	ErrRoomNotFound       = errors.New("poll room not found")
+	ErrRoomClosed         = errors.New("poll is closed")
+	ErrUnknownCandidate   = errors.New("unknown candidate")
+	ErrDuplicateCandidate = errors.New("candidate already in poll")
+	ErrTooManyCandidates  = errors.New("poll has too many candidates")
+	ErrTooManyRooms       = errors.New("too many open polls")
+)
+
+const (
+	maxCandidates = 20
+	// closedRetention is how long closed rooms stay readable
+	closedRetention = time.Hour
+	// defaultMaxRooms bounds how many rooms a manager holds at once
+	defaultMaxRooms = 1000
+	// idleTimeout is how long an open room may go without changes or
+	// subscribers before a sweep closes and drops it
+	idleTimeout = 2 * time.Hour
+	// sweepInterval is how often Create sweeps for idle rooms
+	sweepInterval = 10 * time.Minute
+)
+
+// Candidate is a restaurant in a poll with its current tally
+type Candidate struct {
+	Restaurant structure.Restaurant `json:"restaurant"`
+	Votes      int                  `json:"votes"`
+	AddedBy    string               `json:"added_by,omitempty"`
+}
+
+// State is a snapshot of a room sent to clients
+type State struct {
+	ID         string                `json:"id"`
+	Title      string                `json:"title"`
+	Candidates []Candidate           `json:"candidates"`
+	TotalVotes int                   `json:"total_votes"`
+	Deadline   time.Time             `json:"deadline"`
+	Closed     bool                  `json:"closed"`
+	Winner     *structure.Restaurant `json:"winner,omitempty"`
+}
+
+// Room is a single lunch poll
+type Room struct {
+	mu          sync.Mutex
+	id          string
+	title       string
+	candidates  []Candidate
+	votes       map[string]string
+	deadline    time.Time
+	closed      bool
+	winner      *structure.Restaurant
+	subscribers map[chan State]bool
+	timer       *time.Timer
+	// lastActivity is when the room was created or last changed
+	lastActivity time.Time
+}
+
+// Manager owns every open room
+type Manager struct {
+	mu        sync.Mutex
+	rooms     map[string]*Room
+	maxRooms  int
+	lastSweep time.Time
+}
+
+// DefaultManager is shared by the poll handlers
+var DefaultManager = NewManager()
+
+// NewManager creates an empty room manager
+func NewManager() *Manager {
+	return &Manager{rooms: make(map[string]*Room), maxRooms: defaultMaxRooms, lastSweep: time.Now()}
+}
+
+// Create opens a room seeded with candidates that closes at deadline. Idle
+// rooms are swept periodically, and always before a full manager turns a
+// new room away.
+func (m *Manager) Create(title string, seed []structure.Restaurant, deadline time.Time) (*Room, error) {
+	id, err := newRoomID()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if len(m.rooms) >= m.maxRooms || now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweepLocked(now)
+	}
+	if len(m.rooms) >= m.maxRooms {
+		return nil, ErrTooManyRooms
+	}
+
+	room := &Room{
+		id:           id,
+		title:        title,
+		votes:        make(map[string]string),
+		deadline:     deadline,
+		subscribers:  make(map[chan State]bool),
+		lastActivity: now,
+	}
+	for _, restaurant := range seed {
+		if len(room.candidates) >= maxCandidates {
+			break
+		}
+		room.candidates = append(room.candidates, Candidate{Restaurant: restaurant})
+	}
+
+	// Arm the deadline under the room lock so Close never sees a nil timer
+	room.mu.Lock()
+	room.timer = time.AfterFunc(time.Until(deadline), func() {
+		room.Close()
+		time.AfterFunc(closedRetention, func() { m.remove(id) })
+	})
+	room.mu.Unlock()
+
+	m.rooms[id] = room
+	return room, nil
+}
+
+// sweepLocked closes and drops every idle room
+func (m *Manager) sweepLocked(now time.Time) {
+	m.lastSweep = now
+	for id, room := range m.rooms {
+		if room.idle(now) {
+			room.Close()
+			delete(m.rooms, id)
+		}
+	}
+}
+
+// Get returns the room with id
+func (m *Manager) Get(id string) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, ok := m.rooms[id]
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+	return room, nil
+}
+
+func (m *Manager) remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.rooms, id)
+}
+
+// idle reports whether the room is open but has had no changes and no
+// subscribers for idleTimeout
+func (r *Room) idle(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.closed && len(r.subscribers) == 0 && now.Sub(r.lastActivity) >= idleTimeout
+}
+
+// ID returns the room's shareable identifier
+func (r *Room) ID() string {
+	return r.id
+}
+
+// AddCandidate adds a restaurant suggested by member
+func (r *Room) AddCandidate(member string, restaurant structure.Restaurant) (State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return r.stateLocked(), ErrRoomClosed
+	}
+	if len(r.candidates) >= maxCandidates {
+		return r.stateLocked(), ErrTooManyCandidates
+	}
+	for _, candidate := range r.candidates {
+		if candidate.Restaurant.Code == restaurant.Code {
+			return r.stateLocked(), ErrDuplicateCandidate
+		}
+	}
+
+	r.candidates = append(r.candidates, Candidate{Restaurant: restaurant, AddedBy: member})
+	return r.broadcastLocked(), nil
+}
+
+// Vote records member's vote for the candidate with code, replacing any
+// earlier vote by the same member
+func (r *Room) Vote(member, code string) (State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return r.stateLocked(), ErrRoomClosed
+	}
+
+	found := false
+	for _, candidate := range r.candidates {
+		if candidate.Restaurant.Code == code {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return r.stateLocked(), ErrUnknownCandidate
+	}
+
+	r.votes[member] = code
+	return r.broadcastLocked(), nil
+}
+
+// Close ends the poll and picks the winner. Ties go to the candidate that
+// was added first, and a poll nobody voted in has no winner.
+func (r *Room) Close() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return r.stateLocked()
+	}
+	r.closed = true
+	r.timer.Stop()
+
+	state := r.stateLocked()
+	best := -1
+	for i, candidate := range state.Candidates {
+		if best < 0 || candidate.Votes > state.Candidates[best].Votes {
+			best = i
+		}
+	}
+	if best >= 0 && state.Candidates[best].Votes > 0 {
+		winner := state.Candidates[best].Restaurant
+		r.winner = &winner
+	}
+
+	state = r.broadcastLocked()
+	for ch := range r.subscribers {
+		close(ch)
+		delete(r.subscribers, ch)
+	}
+	return state
+}
+
+// State returns a snapshot of the room
+func (r *Room) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stateLocked()
+}
+
+// Subscribe returns a channel receiving a state snapshot after every
+// change, starting with the current state. The channel is closed when the
+// poll closes; call the returned function to unsubscribe earlier.
+func (r *Room) Subscribe() (<-chan State, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch := make(chan State, 8)
+	ch <- r.stateLocked()
+	if r.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	r.subscribers[ch] = true
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.subscribers[ch] {
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (r *Room) stateLocked() State {
+	tallies := make(map[string]int)
+	for _, code := range r.votes {
+		tallies[code]++
+	}
+
+	state := State{
+		ID:         r.id,
+		Title:      r.title,
+		TotalVotes: len(r.votes),
+		Deadline:   r.deadline,
+		Closed:     r.closed,
+		Winner:     r.winner,
+	}
+	for _, candidate := range r.candidates {
+		candidate.Votes = tallies[candidate.Restaurant.Code]
+		state.Candidates = append(state.Candidates, candidate)
+	}
+	return state
+}
+
+// broadcastLocked sends the current state to every subscriber. A
+// subscriber that is not keeping up loses its oldest buffered snapshot
+// rather than this one, so the latest state (and the closing state with the
+// winner) always arrives.
+func (r *Room) broadcastLocked() State {
+	r.lastActivity = time.Now()
+	state := r.stateLocked()
+	for ch := range r.subscribers {
+		select {
+		case ch <- state:
+			continue
+		default:
+		}
+
+		// Only broadcastLocked sends, and it holds the lock, so once a slot
+		// is freed the send below cannot block
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- state:
+		default:
+		}
+	}
+	return state
+}
+
+func newRoomID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,161 @@
+package poll
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"what-to-eat/pkg/structure"
+)
+
+func seed(codes ...string) []structure.Restaurant {
+	var restaurants []structure.Restaurant
+	for _, code := range codes {
+		restaurants = append(restaurants, structure.Restaurant{Code: code, Name: code})
+	}
+	return restaurants
+}
+
+func TestSlowSubscriberReceivesClosingState(t *testing.T) {
+	room, err := NewManager().Create("lunch", seed("a", "b"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates, unsubscribe := room.Subscribe()
+	defer unsubscribe()
+
+	// Overflow the subscriber's buffer without reading from it
+	for i := 0; i < 10; i++ {
+		if _, err := room.Vote(fmt.Sprintf("member-%d", i), "b"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	room.Close()
+
+	var last State
+	for state := range updates {
+		last = state
+	}
+	if !last.Closed {
+		t.Fatal("subscriber never saw the closed state")
+	}
+	if last.Winner == nil || last.Winner.Code != "b" {
+		t.Fatalf("winner = %v, want b", last.Winner)
+	}
+	if last.TotalVotes != 10 {
+		t.Fatalf("total votes = %d, want 10", last.TotalVotes)
+	}
+}
+
+func TestCloseBreaksTiesByInsertionOrder(t *testing.T) {
+	room, err := NewManager().Create("lunch", seed("a", "b"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	room.Vote("alice", "b")
+	room.Vote("bob", "a")
+
+	if state := room.Close(); state.Winner == nil || state.Winner.Code != "a" {
+		t.Fatalf("winner = %v, want a", state.Winner)
+	}
+}
+
+func TestVoteReplacesEarlierVote(t *testing.T) {
+	room, err := NewManager().Create("lunch", seed("a", "b"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	room.Vote("alice", "a")
+	state, err := room.Vote("alice", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.TotalVotes != 1 || state.Candidates[0].Votes != 0 || state.Candidates[1].Votes != 1 {
+		t.Fatalf("unexpected tally: %+v", state)
+	}
+}
+
+func TestClosedRoomRejectsChanges(t *testing.T) {
+	room, err := NewManager().Create("lunch", seed("a"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	room.Close()
+
+	if _, err := room.Vote("alice", "a"); err != ErrRoomClosed {
+		t.Fatalf("Vote error = %v, want ErrRoomClosed", err)
+	}
+	if _, err := room.AddCandidate("alice", structure.Restaurant{Code: "c"}); err != ErrRoomClosed {
+		t.Fatalf("AddCandidate error = %v, want ErrRoomClosed", err)
+	}
+}
+
+func TestDeadlineClosesRoom(t *testing.T) {
+	room, err := NewManager().Create("lunch", seed("a"), time.Now().Add(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates, unsubscribe := room.Subscribe()
+	defer unsubscribe()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case state, open := <-updates:
+			if !open {
+				t.Fatal("channel closed without a closed state")
+			}
+			if state.Closed {
+				return
+			}
+		case <-timeout:
+			t.Fatal("room did not close at its deadline")
+		}
+	}
+}
+
+func TestCloseWithoutVotesHasNoWinner(t *testing.T) {
+	room, err := NewManager().Create("lunch", seed("a", "b"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := room.Close(); !state.Closed || state.Winner != nil {
+		t.Fatalf("closed = %v, winner = %v; want a closed poll without a winner", state.Closed, state.Winner)
+	}
+}
+
+func TestManagerCapsRoomsAndSweepsIdleOnes(t *testing.T) {
+	m := NewManager()
+	m.maxRooms = 2
+	deadline := time.Now().Add(time.Hour)
+
+	stale, _ := m.Create("stale", seed("a"), deadline)
+	busy, _ := m.Create("busy", seed("a"), deadline)
+	if _, err := m.Create("third", seed("a"), deadline); err != ErrTooManyRooms {
+		t.Fatalf("Create error = %v, want ErrTooManyRooms", err)
+	}
+
+	// An idle room makes way; one with a subscriber is kept however quiet
+	longAgo := time.Now().Add(-idleTimeout)
+	for _, room := range []*Room{stale, busy} {
+		room.mu.Lock()
+		room.lastActivity = longAgo
+		room.mu.Unlock()
+	}
+	_, unsubscribe := busy.Subscribe()
+	defer unsubscribe()
+
+	if _, err := m.Create("third", seed("a"), deadline); err != nil {
+		t.Fatalf("Create after idle room: %v", err)
+	}
+	if _, err := m.Get(stale.ID()); err != ErrRoomNotFound {
+		t.Errorf("idle room still held: %v", err)
+	}
+	if !stale.State().Closed {
+		t.Error("swept room was not closed")
+	}
+	if _, err := m.Get(busy.ID()); err != nil {
+		t.Errorf("subscribed room was swept: %v", err)
+	}
+}
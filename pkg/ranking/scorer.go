@@ -0,0 +1,107 @@
+package ranking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"what-to-eat/pkg/structure"
+)
+
+// Scorer turns a restaurant into the weight used by weighted and top-k draws
+type Scorer interface {
+	// Name identifies the scorer in requests and responses
+	Name() string
+	// Score rates r against statistics of the whole candidate set
+	Score(r structure.Restaurant, stats Stats) structure.ScoreBreakdown
+}
+
+// Stats summarises the candidate set a restaurant is scored against
+type Stats struct {
+	Count           int
+	MeanRating      float64
+	MeanDeliveryFee float64
+}
+
+// ComputeStats summarises candidates. MeanRating is weighted by review count
+// so a handful of lone five-star reviews can't drag it up.
+func ComputeStats(candidates []structure.Restaurant) Stats {
+	stats := Stats{Count: len(candidates)}
+	if len(candidates) == 0 {
+		return stats
+	}
+
+	var ratingSum, reviewSum, feeSum float64
+	for _, c := range candidates {
+		if c.ReviewNumber > 0 {
+			ratingSum += c.Rating * float64(c.ReviewNumber)
+			reviewSum += float64(c.ReviewNumber)
+		}
+		feeSum += c.DeliveryFee
+	}
+	if reviewSum > 0 {
+		stats.MeanRating = ratingSum / reviewSum
+	}
+	stats.MeanDeliveryFee = feeSum / float64(len(candidates))
+	return stats
+}
+
+// scorers holds the built-in strategies by name
+var scorers = map[string]Scorer{}
+
+func register(s Scorer) {
+	scorers[s.Name()] = s
+}
+
+func init() {
+	register(Legacy{})
+	register(Bayesian{PriorReviews: defaultPriorReviews})
+	register(Wilson{Z: defaultWilsonZ})
+	register(Utility{
+		Quality:        Bayesian{PriorReviews: defaultPriorReviews},
+		DistanceScale:  defaultDistanceScale,
+		FeeSensitivity: defaultFeeSensitivity,
+	})
+}
+
+// Default is used when a request doesn't name a scorer
+var Default Scorer = Bayesian{PriorReviews: defaultPriorReviews}
+
+// Lookup returns the scorer with the given name, or Default for an empty
+// name
+func Lookup(name string) (Scorer, error) {
+	if name == "" {
+		return Default, nil
+	}
+	s, ok := scorers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown scorer: %s (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return s, nil
+}
+
+// Names lists the registered scorers in alphabetical order
+func Names() []string {
+	names := make([]string, 0, len(scorers))
+	for name := range scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply scores every candidate in place, setting Weight and, when explain is
+// set, the score breakdown
+func Apply(s Scorer, candidates []structure.Restaurant, explain bool) {
+	if s == nil {
+		s = Default
+	}
+	stats := ComputeStats(candidates)
+	for i := range candidates {
+		breakdown := s.Score(candidates[i], stats)
+		candidates[i].Weight = breakdown.Value
+		if explain {
+			candidates[i].Score = &breakdown
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ranking
+
+import (
+	"math"
+	"testing"
+
+	"what-to-eat/pkg/structure"
+)
+
+func restaurant(rating float64, reviews int) structure.Restaurant {
+	return structure.Restaurant{Rating: rating, ReviewNumber: reviews}
+}
+
+func TestBayesianShrinksTowardsLocalMean(t *testing.T) {
+	b := Bayesian{PriorReviews: 20}
+	stats := Stats{MeanRating: 4.0}
+
+	if got := b.Score(restaurant(0, 0), stats).Value; got != 4.0 {
+		t.Errorf("unreviewed score = %v, want the local mean 4.0", got)
+	}
+	// 20 reviews at 5.0 against a prior of 20 at 4.0 lands halfway
+	if got := b.Score(restaurant(5, 20), stats).Value; math.Abs(got-4.5) > 1e-9 {
+		t.Errorf("score = %v, want 4.5", got)
+	}
+	few := b.Score(restaurant(5, 3), stats).Value
+	many := b.Score(restaurant(4.8, 500), stats).Value
+	if few >= many {
+		t.Errorf("3 reviews at 5.0 (%v) outscored 500 at 4.8 (%v)", few, many)
+	}
+}
+
+func TestWilsonLowerBound(t *testing.T) {
+	w := Wilson{Z: 1.96}
+
+	if got := w.Score(restaurant(5, 0), Stats{}).Value; got != 0 {
+		t.Errorf("unreviewed score = %v, want 0", got)
+	}
+	// All 10 customers satisfied: the lower bound is n / (n + z^2)
+	if got, want := w.Score(restaurant(5, 10), Stats{}).Value, 10/(10+1.96*1.96); math.Abs(got-want) > 1e-9 {
+		t.Errorf("score = %v, want %v", got, want)
+	}
+	// p = 0.75 with many reviews approaches 0.75 from below
+	if got := w.Score(restaurant(4, 100000), Stats{}).Value; got >= 0.75 || got < 0.74 {
+		t.Errorf("score = %v, want just under 0.75", got)
+	}
+	few := w.Score(restaurant(5, 3), Stats{}).Value
+	many := w.Score(restaurant(4.8, 500), Stats{}).Value
+	if few >= many {
+		t.Errorf("3 reviews at 5.0 (%v) outscored 500 at 4.8 (%v)", few, many)
+	}
+}
+
+func TestComputeStatsWeighsRatingsByReviews(t *testing.T) {
+	stats := ComputeStats([]structure.Restaurant{
+		{Rating: 5, ReviewNumber: 1, DeliveryFee: 10},
+		{Rating: 4, ReviewNumber: 9, DeliveryFee: 30},
+		{Rating: 0, ReviewNumber: 0, DeliveryFee: 20},
+	})
+	if math.Abs(stats.MeanRating-4.1) > 1e-9 {
+		t.Errorf("MeanRating = %v, want 4.1", stats.MeanRating)
+	}
+	if stats.MeanDeliveryFee != 20 || stats.Count != 3 {
+		t.Errorf("stats = %+v, want a mean fee of 20 over 3", stats)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if s, err := Lookup(""); err != nil || s != Default {
+		t.Errorf("Lookup(\"\") = %v, %v; want Default", s, err)
+	}
+	if s, err := Lookup("Wilson"); err != nil || s.Name() != "wilson" {
+		t.Errorf("Lookup(Wilson) = %v, %v", s, err)
+	}
+	if _, err := Lookup("magic"); err == nil {
+		t.Error("unknown scorer accepted")
+	}
+}
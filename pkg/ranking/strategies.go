@@ -0,0 +1,138 @@
+package ranking
+
+import (
+	"math"
+
+	"what-to-eat/pkg/structure"
+)
+
+const (
+	defaultPriorReviews   = 20.0
+	defaultWilsonZ        = 1.96
+	defaultDistanceScale  = 2.0
+	defaultFeeSensitivity = 0.5
+)
+
+// Legacy is the original promote formula. It is kept for comparison; note
+// that it scores a restaurant with no reviews at exactly 1.0.
+type Legacy struct{}
+
+func (Legacy) Name() string { return "legacy" }
+
+func (Legacy) Score(r structure.Restaurant, _ Stats) structure.ScoreBreakdown {
+	maxReview := 200.0
+	offset := 50.0
+	weight := math.Min(1.0, maxReview/float64(r.ReviewNumber))
+	value := (float64(r.ReviewNumber)*r.Rating + offset*weight) / (float64(r.ReviewNumber) + offset*weight)
+
+	return structure.ScoreBreakdown{
+		Scorer: "legacy",
+		Value:  value,
+		Components: map[string]float64{
+			"rating":  r.Rating,
+			"reviews": float64(r.ReviewNumber),
+		},
+	}
+}
+
+// Bayesian shrinks each rating towards the local mean, as if every
+// restaurant started with PriorReviews reviews at that mean. Unreviewed
+// restaurants score exactly the local mean.
+type Bayesian struct {
+	PriorReviews float64
+}
+
+func (Bayesian) Name() string { return "bayesian" }
+
+func (b Bayesian) Score(r structure.Restaurant, stats Stats) structure.ScoreBreakdown {
+	reviews := float64(r.ReviewNumber)
+	value := stats.MeanRating
+	if reviews+b.PriorReviews > 0 {
+		value = (reviews*r.Rating + b.PriorReviews*stats.MeanRating) / (reviews + b.PriorReviews)
+	}
+
+	return structure.ScoreBreakdown{
+		Scorer: "bayesian",
+		Value:  value,
+		Components: map[string]float64{
+			"rating":        r.Rating,
+			"reviews":       reviews,
+			"local_mean":    stats.MeanRating,
+			"prior_reviews": b.PriorReviews,
+		},
+	}
+}
+
+// Wilson scores the lower bound of the Wilson interval for the share of
+// satisfied customers, reading a 1-5 rating as a fraction between 0 and 1.
+// It is deliberately pessimistic: unreviewed restaurants score 0.
+type Wilson struct {
+	Z float64
+}
+
+func (Wilson) Name() string { return "wilson" }
+
+func (w Wilson) Score(r structure.Restaurant, _ Stats) structure.ScoreBreakdown {
+	n := float64(r.ReviewNumber)
+	p := math.Max(0, math.Min(1, (r.Rating-1)/4))
+
+	var value float64
+	if n > 0 {
+		z2 := w.Z * w.Z
+		centre := p + z2/(2*n)
+		margin := w.Z * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+		value = (centre - margin) / (1 + z2/n)
+	}
+
+	return structure.ScoreBreakdown{
+		Scorer: "wilson",
+		Value:  value,
+		Components: map[string]float64{
+			"rating":   r.Rating,
+			"reviews":  n,
+			"positive": p,
+			"z":        w.Z,
+		},
+	}
+}
+
+// Utility discounts a quality score by distance and delivery fee. Each
+// DistanceScale kilometres divides the score by e, and a fee at the local
+// mean costs FeeSensitivity in the same way.
+type Utility struct {
+	Quality        Scorer
+	DistanceScale  float64
+	FeeSensitivity float64
+}
+
+func (Utility) Name() string { return "utility" }
+
+func (u Utility) Score(r structure.Restaurant, stats Stats) structure.ScoreBreakdown {
+	quality := u.Quality.Score(r, stats)
+
+	distanceFactor := 1.0
+	if u.DistanceScale > 0 {
+		distanceFactor = math.Exp(-r.Distance / u.DistanceScale)
+	}
+	feeFactor := 1.0
+	if stats.MeanDeliveryFee > 0 {
+		feeFactor = math.Exp(-u.FeeSensitivity * r.DeliveryFee / stats.MeanDeliveryFee)
+	}
+
+	components := map[string]float64{
+		"quality":         quality.Value,
+		"distance":        r.Distance,
+		"distance_factor": distanceFactor,
+		"delivery_fee":    r.DeliveryFee,
+		"fee_factor":      feeFactor,
+	}
+	for name, value := range quality.Components {
+		components[name] = value
+	}
+
+	return structure.ScoreBreakdown{
+		Scorer:     "utility",
+		Value:      quality.Value * distanceFactor * feeFactor,
+		Components: components,
+	}
+}
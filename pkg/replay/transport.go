@@ -0,0 +1,206 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode selects how the transport treats upstream traffic
+type Mode string
+
+const (
+	// ModeOff passes requests straight through
+	ModeOff Mode = ""
+	// ModeRecord passes requests through and saves each exchange as a fixture
+	ModeRecord Mode = "record"
+	// ModeReplay answers requests from fixtures without touching the network
+	ModeReplay Mode = "replay"
+)
+
+// ErrNoFixture is returned in replay mode when a request was never recorded
+var ErrNoFixture = errors.New("no recorded fixture for request")
+
+// Transport is an http.RoundTripper that records upstream exchanges into
+// fixture files and replays them deterministically
+type Transport struct {
+	mode Mode
+	dir  string
+	base http.RoundTripper
+	mu   sync.Mutex
+}
+
+// fixture is the on-disk form of a recorded exchange. Request headers are
+// deliberately not stored so credentials never end up in fixtures.
+type fixture struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+		Body   string `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		StatusCode int               `json:"status_code"`
+		Header     map[string]string `json:"header"`
+		Body       json.RawMessage   `json:"body,omitempty"`
+		RawBody    string            `json:"raw_body,omitempty"`
+	} `json:"response"`
+}
+
+// New creates a transport for mode, storing fixtures in dir. A nil base
+// uses http.DefaultTransport.
+func New(mode Mode, dir string, base http.RoundTripper) (*Transport, error) {
+	switch mode {
+	case ModeOff, ModeRecord, ModeReplay:
+	default:
+		return nil, fmt.Errorf("unknown replay mode: %s", mode)
+	}
+	if mode != ModeOff && dir == "" {
+		return nil, fmt.Errorf("fixture directory is required in %s mode", mode)
+	}
+	if mode == ModeRecord {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create fixture directory: %w", err)
+		}
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{mode: mode, dir: dir, base: base}, nil
+}
+
+// Mode returns the mode the transport was created with
+func (t *Transport) Mode() Mode {
+	return t.mode
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.mode == ModeOff {
+		return t.base.RoundTrip(req)
+	}
+
+	// Read the body so it can be part of the fixture key
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	path := t.path(req, body)
+
+	if t.mode == ModeReplay {
+		return t.replay(req, path)
+	}
+	return t.record(req, body, path)
+}
+
+// path names the fixture after the host and a hash of method, URL and body
+func (t *Transport) path(req *http.Request, body []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL.String())
+	h.Write(body)
+
+	host := strings.NewReplacer(".", "_", ":", "_").Replace(req.URL.Host)
+	return filepath.Join(t.dir, host+"-"+hex.EncodeToString(h.Sum(nil))[:16]+".json")
+}
+
+func (t *Transport) replay(req *http.Request, path string) (*http.Response, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoFixture, req.Method, req.URL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+
+	body := []byte(f.Response.RawBody)
+	if len(f.Response.Body) > 0 {
+		// Undo the indentation added when the fixture was written
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, f.Response.Body); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+		body = compact.Bytes()
+	}
+
+	header := make(http.Header)
+	for key, value := range f.Response.Header {
+		header.Set(key, value)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Response.StatusCode, http.StatusText(f.Response.StatusCode)),
+		StatusCode:    f.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *Transport) record(req *http.Request, body []byte, path string) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var f fixture
+	f.Request.Method = req.Method
+	f.Request.URL = req.URL.String()
+	f.Request.Body = string(body)
+	f.Response.StatusCode = resp.StatusCode
+	f.Response.Header = map[string]string{}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		f.Response.Header["Content-Type"] = contentType
+	}
+	// Keep JSON bodies readable in the fixture file
+	if json.Valid(respBody) {
+		f.Response.Body = respBody
+	} else {
+		f.Response.RawBody = string(respBody)
+	}
+
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	t.mu.Lock()
+	err = os.WriteFile(path, raw, 0o644)
+	t.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+	resp.Header.Del("Content-Encoding")
+	return resp, nil
+}
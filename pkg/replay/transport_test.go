@@ -0,0 +1,106 @@
+package replay
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, method, url, body string) (int, string, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data), nil
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"echo": "` + string(body) + `", "query": "` + r.URL.RawQuery + `"}`))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not here"))
+		}
+	}))
+	dir := t.TempDir()
+
+	recorder, err := New(ModeRecord, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: recorder}
+
+	_, recordedJSON, err := get(t, client, "POST", server.URL+"/json?x=1", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := get(t, client, "POST", server.URL+"/json?x=1", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := get(t, client, "GET", server.URL+"/missing", ""); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 3 {
+		t.Fatalf("recorded %d fixtures, want 3 (the body is part of the key)", len(files))
+	}
+	for _, f := range files {
+		raw, _ := os.ReadFile(dir + "/" + f.Name())
+		if strings.Contains(string(raw), "secret") {
+			t.Errorf("fixture %s contains request credentials", f.Name())
+		}
+	}
+
+	replayer, err := New(ModeReplay, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: replayer}
+
+	status, body, err := get(t, client, "POST", server.URL+"/json?x=1", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusOK || body != `{"echo":"a","query":"x=1"}` {
+		t.Errorf("replayed %d %s, want 200 with the compacted recording of %s", status, body, recordedJSON)
+	}
+
+	status, body, err = get(t, client, "GET", server.URL+"/missing", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusNotFound || body != "not here" {
+		t.Errorf("replayed %d %q, want the recorded 404", status, body)
+	}
+
+	if _, _, err := get(t, client, "GET", server.URL+"/json?x=2", ""); !errors.Is(err, ErrNoFixture) {
+		t.Errorf("unrecorded request error = %v, want ErrNoFixture", err)
+	}
+}
+
+func TestNewValidatesMode(t *testing.T) {
+	if _, err := New("rewind", t.TempDir(), nil); err == nil {
+		t.Error("unknown mode accepted")
+	}
+	if _, err := New(ModeReplay, "", nil); err == nil {
+		t.Error("replay mode accepted without a fixture directory")
+	}
+}
@@ -1,13 +1,17 @@
 package structure
 
 import (
+	"net/http"
 	"time"
 )
 
 type FoodPandaMenuResponse struct {
 	StatusCode int `json:"status_code"`
 	Data       struct {
-		Menus []FoodPandaMenu `json:"menus"`
+		Code    string          `json:"code"`
+		Name    string          `json:"name"`
+		WebPath string          `json:"web_path"`
+		Menus   []FoodPandaMenu `json:"menus"`
 	} `json:"data"`
 }
 
@@ -52,12 +56,12 @@ type ProductVariation struct {
 	ID             int         `json:"id"`
 	Code           string      `json:"code"`
 	RemoteCode     string      `json:"remote_code"`
-	ContainerPrice int         `json:"container_price"`
+	ContainerPrice float64     `json:"container_price"`
 	Name           string      `json:"name,omitempty"`
-	Price          int         `json:"price"`
+	Price          float64     `json:"price"`
 	ToppingIDs     []int       `json:"topping_ids"`
 	UnitPricing    interface{} `json:"unit_pricing"`
-	TotalPrice     int         `json:"total_price"`
+	TotalPrice     float64     `json:"total_price"`
 }
 
 type Topping struct {
@@ -71,12 +75,12 @@ type Topping struct {
 }
 
 type ToppingOption struct {
-	ID          int    `json:"id"`
-	ProductID   int    `json:"product_id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Price       int    `json:"price"`
-	RemoteCode  string `json:"remote_code"`
+	ID          int     `json:"id"`
+	ProductID   int     `json:"product_id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	RemoteCode  string  `json:"remote_code"`
 }
 
 type MenuTag struct {
@@ -126,11 +130,22 @@ type RestaurantItem struct {
 		IsPickupAvailable   bool `json:"is_pickup_available"`
 		IsTemporaryClosed   bool `json:"is_temporary_closed"`
 	} `json:"metadata"`
-	MinimumDeliveryFee  float64 `json:"minimum_delivery_fee"`
-	MinimumDeliveryTime float64 `json:"minimum_delivery_time"`
-	MinimumOrderAmount  float64 `json:"minimum_order_amount"`
-	Latitude            float64 `json:"latitude"`
-	Longitude           float64 `json:"longitude"`
+	MinimumDeliveryFee  float64          `json:"minimum_delivery_fee"`
+	MinimumDeliveryTime float64          `json:"minimum_delivery_time"`
+	MinimumOrderAmount  float64          `json:"minimum_order_amount"`
+	Latitude            float64          `json:"latitude"`
+	Longitude           float64          `json:"longitude"`
+	Schedules           []VendorSchedule `json:"schedules"`
+}
+
+// VendorSchedule is one weekly opening window. Weekday runs from 1 (Monday)
+// to 7 (Sunday) and times are "HH:MM" in the market's local time.
+type VendorSchedule struct {
+	ID          int    `json:"id"`
+	Weekday     int    `json:"weekday"`
+	OpeningType string `json:"opening_type"`
+	OpeningTime string `json:"opening_time"`
+	ClosingTime string `json:"closing_time"`
 }
 
 // AggregationsData represents the aggregations section of the response
@@ -158,6 +173,7 @@ type Location struct {
 // Restaurant represents our processed restaurant data for response
 type Restaurant struct {
 	ID    int    `json:"id"`
+	Code  string `json:"code"`
 	Name  string `json:"name"`
 	Chain struct {
 		Code           string `json:"code"`
@@ -165,22 +181,103 @@ type Restaurant struct {
 		MainVendorCode string `json:"main_vendor_code"`
 		URLKey         string `json:"url_key"`
 	} `json:"chain"`
-	HeroImage          string  `json:"hero_image"`
-	Address            string  `json:"address"`
-	Distance           float64 `json:"distance"`
-	Rating             float64 `json:"rating"`
-	ReviewNumber       int     `json:"review_number"`
-	RedirectionURL     string  `json:"redirection_url"`
-	MinimumOrderAmount float64 `json:"minimum_order_amount"`
-	DeliveryFee        float64 `json:"delivery_fee"`
-	DeliveryTime       float64 `json:"delivery_time"`
-	Weight             float64 `json:"weight"`
+	HeroImage          string         `json:"hero_image"`
+	Address            string         `json:"address"`
+	Distance           float64        `json:"distance"`
+	Rating             float64        `json:"rating"`
+	ReviewNumber       int            `json:"review_number"`
+	RedirectionURL     string         `json:"redirection_url"`
+	MinimumOrderAmount float64        `json:"minimum_order_amount"`
+	DeliveryFee        float64        `json:"delivery_fee"`
+	DeliveryTime       float64        `json:"delivery_time"`
+	Budget             int            `json:"budget"`
+	HasDiscount        bool           `json:"has_discount"`
+	Latitude           float64        `json:"latitude"`
+	Longitude          float64        `json:"longitude"`
+	WalkingDistance    float64        `json:"walking_distance,omitempty"`
+	WalkingTime        float64        `json:"walking_time,omitempty"`
+	Branches           int            `json:"branches,omitempty"`
+	Weight             float64        `json:"weight"`
+	Cuisines           []CuisineLabel `json:"cuisines,omitempty"`
+	// Score explains Weight when the request asks for it
+	Score *ScoreBreakdown `json:"score,omitempty"`
+}
+
+// Market identifies the Delivery Hero country and language a request was served from
+type Market struct {
+	Country    string `json:"country"`
+	Language   string `json:"language"`
+	LanguageID string `json:"language_id"`
+}
+
+// ScoreBreakdown shows how a scorer arrived at a restaurant's weight
+type ScoreBreakdown struct {
+	Scorer     string             `json:"scorer"`
+	Value      float64            `json:"value"`
+	Components map[string]float64 `json:"components"`
+}
+
+// SelectionInfo describes how the returned restaurant was drawn
+type SelectionInfo struct {
+	Mode        string  `json:"mode"`
+	Scorer      string  `json:"scorer,omitempty"`
+	Probability float64 `json:"probability"`
+	Candidates  int     `json:"candidates"`
+	TopK        int     `json:"top_k,omitempty"`
+	// MergedBranches counts chain branches collapsed before the draw
+	MergedBranches int `json:"merged_branches,omitempty"`
+	// UnknownHours counts candidates kept by an at= filter only because
+	// their opening hours are unknown
+	UnknownHours int `json:"unknown_hours,omitempty"`
+	// Probabilities holds the chance of each draw when several restaurants
+	// are returned, conditional on the draws before it
+	Probabilities []float64 `json:"probabilities,omitempty"`
+	Seed          *int64    `json:"seed,omitempty"`
 }
 
 // ApiResponse represents our API's response structure
 type ApiResponse struct {
 	Restaurants []Restaurant       `json:"restaurants"`
 	Cuisines    []AggregationsData `json:"cuisines"`
+	Market      Market             `json:"market"`
+	Cache       string             `json:"cache"`
+	Selection   SelectionInfo      `json:"selection"`
+	Fulfillment string             `json:"fulfillment"`
+	OrderAt     *time.Time         `json:"order_at,omitempty"`
+	Experiment  *ExperimentTag     `json:"experiment,omitempty"`
+	Language    string             `json:"language,omitempty"`
+}
+
+// ExperimentTag names the experiment variant that shaped a response
+type ExperimentTag struct {
+	Name    string `json:"name"`
+	Variant string `json:"variant"`
+}
+
+// GroupMemberInfo describes what a group pick means for one member
+type GroupMemberInfo struct {
+	Name         string  `json:"name"`
+	Distance     float64 `json:"distance"`
+	DeliveryFee  float64 `json:"delivery_fee"`
+	DeliveryTime float64 `json:"delivery_time"`
+	// Estimated is set when fee and time were quoted for the group centroid
+	// rather than this member's location
+	Estimated bool `json:"estimated"`
+}
+
+// GroupPickResponse is the response of the group picker
+type GroupPickResponse struct {
+	Restaurant Restaurant        `json:"restaurant"`
+	Members    []GroupMemberInfo `json:"members"`
+	Strategy   string            `json:"strategy"`
+	Market     Market            `json:"market"`
+	Selection  SelectionInfo     `json:"selection"`
+}
+
+// CuisineLabel is a cuisine as shown to the user
+type CuisineLabel struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
 }
 
 type CuisineInfo struct {
@@ -190,13 +287,58 @@ type CuisineInfo struct {
 	Slug  string `json:"slug"`
 }
 
+// CuisineStats summarises the restaurants of one cuisine around a location.
+// Medians are zero when no restaurant has the value; MedianRating only
+// counts restaurants with reviews.
+type CuisineStats struct {
+	ID                 int         `json:"id"`
+	Title              string      `json:"title"`
+	Count              int         `json:"count"`
+	RatedCount         int         `json:"rated_count"`
+	MedianRating       float64     `json:"median_rating"`
+	MedianDeliveryFee  float64     `json:"median_delivery_fee"`
+	MedianDeliveryTime float64     `json:"median_delivery_time"`
+	DiscountShare      float64     `json:"discount_share"`
+	Nearest            *Restaurant `json:"nearest,omitempty"`
+}
+
+// CuisineStatsResponse lists per-cuisine statistics, most common first
+type CuisineStatsResponse struct {
+	Cuisines    []CuisineStats `json:"cuisines"`
+	Restaurants int            `json:"restaurants"`
+	// Available is how many vendors Foodpanda lists and Returned how many
+	// the stats were computed from; Truncated is set when they differ
+	Available int    `json:"available"`
+	Returned  int    `json:"returned"`
+	Truncated bool   `json:"truncated"`
+	Market    Market `json:"market"`
+	Cache     string `json:"cache"`
+	Language  string `json:"language"`
+}
+
 type PerimeterConfig struct {
-	Timeout    time.Duration
-	BaseURL    string
-	MaxRetries int
-	Debug      bool
+	Timeout     time.Duration
+	BaseURL     string
+	MenuBaseURL string
+	MaxRetries  int
+	Debug       bool
+	Transport   http.RoundTripper
+
+	// Paging for vendor listings
+	PageSize        int
+	MaxConcurrency  int
+	MaxListingItems int
+
+	// Location-bucketed listing cache
+	CacheTTL      time.Duration
+	CacheStaleTTL time.Duration
+	CacheSize     int
+	CacheCellSize float64
 }
 
 type CuisinesResponse struct {
 	Cuisines []CuisineInfo `json:"cuisines"`
+	Market   Market        `json:"market"`
+	Cache    string        `json:"cache"`
+	Language string        `json:"language"`
 }
@@ -1,17 +1,38 @@
 package vertex
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"what-to-eat/pkg/apierror"
 	"what-to-eat/pkg/structure"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
+// upstreamName labels errors raised by Vertex AI calls
+const upstreamName = "vertex"
+
 var ProjectInfo structure.GeminiProjectInfo
 var tokenSource oauth2.TokenSource
 
+// httpClient is used for all Vertex AI requests
+var httpClient = &http.Client{}
+
+// StaticAccessToken skips Google credentials when set, e.g. when replaying
+// recorded traffic offline
+var StaticAccessToken string
+
+// SetTransport routes Vertex AI requests through rt
+func SetTransport(rt http.RoundTripper) {
+	httpClient = &http.Client{Transport: rt}
+}
+
 // InitProjectInfo initializes the project information
 func InitProjectInfo() {
 	ProjectInfo = structure.GeminiProjectInfo{
@@ -23,6 +44,10 @@ func InitProjectInfo() {
 }
 
 func OauthGoogle() (string, error) {
+	if StaticAccessToken != "" {
+		return StaticAccessToken, nil
+	}
+
 	if tokenSource == nil {
 		// Create a context
 		ctx := context.Background()
@@ -47,3 +72,113 @@ func OauthGoogle() (string, error) {
 
 	return token.AccessToken, nil
 }
+
+// generateContent sends input to the model under systemPrompt and returns
+// the text of its reply
+func generateContent(ctx context.Context, input []byte, systemPrompt, modelID string) (string, error) {
+	// Create AI request payload
+	requestPayload := structure.VertexAIRequest{
+		Contents: []struct {
+			Role  string `json:"role"`
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		}{
+			{
+				Role: "user",
+				Parts: []struct {
+					Text string `json:"text"`
+				}{
+					{
+						Text: string(input),
+					},
+				},
+			},
+		},
+
+		SystemInstruction: struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		}{
+			Parts: []struct {
+				Text string `json:"text"`
+			}{
+				{
+					Text: systemPrompt,
+				},
+			},
+		},
+		GenerationConfig: struct {
+			Temperature     float64 `json:"temperature"`
+			MaxOutputTokens int     `json:"maxOutputTokens"`
+			TopP            float64 `json:"topP"`
+			Seed            int     `json:"seed"`
+		}{
+			Temperature:     0.1,
+			MaxOutputTokens: 8192,
+			TopP:            0.95,
+			Seed:            0,
+		},
+		SafetySettings: []struct {
+			Category  string `json:"category"`
+			Threshold string `json:"threshold"`
+		}{
+			{
+				Category:  "HARM_CATEGORY_HATE_SPEECH",
+				Threshold: "OFF",
+			},
+		},
+	}
+
+	// Convert to JSON
+	payloadBytes, err := json.Marshal(requestPayload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	// Send the request to Vertex AI
+	apiURL := fmt.Sprintf(
+		"https://%s/v1/projects/%s/locations/%s/publishers/google/models/%s:streamGenerateContent",
+		ProjectInfo.ApiEndpoint, ProjectInfo.ProjectID, ProjectInfo.Location, modelID,
+	)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Get google oauth token
+	accessToken, err := OauthGoogle()
+	if err != nil {
+		return "", apierror.New(apierror.KindUnavailable, upstreamName, fmt.Errorf("failed to generate access token: %w", err))
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+
+	// Execute the request
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", apierror.FromTransport(upstreamName, err)
+	}
+	defer resp.Body.Close()
+
+	// Read the response
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", apierror.FromTransport(upstreamName, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", apierror.FromStatus(upstreamName, resp.StatusCode, body)
+	}
+
+	// Parse the response
+	parsedResponse, err := ParseVertexAIResponse(string(body))
+	if err != nil {
+		return "", apierror.Malformed(upstreamName, err)
+	}
+
+	return parsedResponse, nil
+}
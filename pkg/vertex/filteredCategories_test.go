@@ -0,0 +1,87 @@
+package vertex
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"what-to-eat/pkg/experiment"
+	"what-to-eat/pkg/replay"
+)
+
+// useFilterExperiment enrolls every client in a two-variant filter
+// experiment and returns the store its outcomes go to
+func useFilterExperiment(t *testing.T) *experiment.MemoryStore {
+	t.Helper()
+	registry, err := experiment.NewRegistry([]experiment.Experiment{{
+		Name:    "filter-prompt",
+		Surface: experiment.SurfaceFilter,
+		Variants: []experiment.Variant{
+			{Name: "control"},
+			{Name: "v2", PromptVersion: "v2"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := experiment.NewMemoryStore(0)
+
+	previousRegistry, previousStore := experiment.Default, experiment.DefaultStore
+	experiment.Default, experiment.DefaultStore = registry, store
+	t.Cleanup(func() { experiment.Default, experiment.DefaultStore = previousRegistry, previousStore })
+	return store
+}
+
+// useReplayVertex answers Vertex AI calls from the fixtures in dir
+func useReplayVertex(t *testing.T, dir string) {
+	t.Helper()
+	transport, err := replay.New(replay.ModeReplay, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousClient, previousToken := httpClient, StaticAccessToken
+	SetTransport(transport)
+	StaticAccessToken = "replay"
+	t.Cleanup(func() { httpClient, StaticAccessToken = previousClient, previousToken })
+}
+
+const ramenOrPizza = `{"userPreference": "想吃拉麵", "availableCategories": [{"id": 1203, "label": "拉麵"}, {"id": 165, "label": "披薩"}]}`
+
+func TestFilteredCategoriesOfflineModeIsNotExposed(t *testing.T) {
+	store := useFilterExperiment(t)
+
+	r := httptest.NewRequest("POST", "/filter?mode=offline&clientId=abc", strings.NewReader(ramenOrPizza))
+	w := httptest.NewRecorder()
+	FilteredCategories(w, r)
+
+	if got := w.Header().Get("X-Matcher"); got != FilterModeOffline {
+		t.Errorf("X-Matcher = %q, want %q", got, FilterModeOffline)
+	}
+	if got := w.Header().Get("X-Experiment"); got != "" {
+		t.Errorf("X-Experiment = %q, want none when the variant is never used", got)
+	}
+	if outcomes, _ := store.Outcomes("filter-prompt"); len(outcomes) != 0 {
+		t.Errorf("outcomes = %+v, want none", outcomes)
+	}
+}
+
+func TestFilteredCategoriesFallbackIsExposed(t *testing.T) {
+	store := useFilterExperiment(t)
+	// No fixtures, so the model call fails and auto mode falls back
+	useReplayVertex(t, t.TempDir())
+
+	r := httptest.NewRequest("POST", "/filter?clientId=abc", strings.NewReader(ramenOrPizza))
+	w := httptest.NewRecorder()
+	FilteredCategories(w, r)
+
+	if got := w.Header().Get("X-Matcher"); got != FilterModeOffline {
+		t.Errorf("X-Matcher = %q, want the offline fallback", got)
+	}
+	if got := w.Header().Get("X-Experiment"); !strings.HasPrefix(got, "filter-prompt/") {
+		t.Errorf("X-Experiment = %q, want the filter-prompt assignment", got)
+	}
+	outcomes, _ := store.Outcomes("filter-prompt")
+	if len(outcomes) != 1 || outcomes[0].Event != experiment.EventExposure || outcomes[0].ClientID != "abc" {
+		t.Errorf("outcomes = %+v, want one exposure for abc", outcomes)
+	}
+}
@@ -0,0 +1,265 @@
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/structure"
+)
+
+// MenuProvider fetches menus for a set of vendors. Results are keyed by
+// vendor code in the puppeteer service's format; vendors that could not be
+// fetched map to an object with "code" and "error" fields.
+type MenuProvider interface {
+	FetchMenus(ctx context.Context, codes []string, location structure.Location, market structure.Market) (map[string]interface{}, error)
+}
+
+// Menus is the provider used by the suggestion flow
+var Menus MenuProvider = NewPuppeteerMenuProvider(PuppeteerConfig{})
+
+// menuServiceName labels errors raised by the puppeteer menu service
+const menuServiceName = "menu-service"
+
+// PuppeteerConfig configures the puppeteer menu service client
+type PuppeteerConfig struct {
+	URL        string
+	AuthHeader string
+	Timeout    time.Duration
+	Transport  http.RoundTripper
+}
+
+// PuppeteerMenuProvider fetches menus from the Node puppeteer service
+type PuppeteerMenuProvider struct {
+	config PuppeteerConfig
+	client *http.Client
+}
+
+// NewPuppeteerMenuProvider creates a provider, defaulting to the service on
+// localhost:3001 with a 10 second timeout
+func NewPuppeteerMenuProvider(config PuppeteerConfig) *PuppeteerMenuProvider {
+	if config.URL == "" {
+		config.URL = "http://localhost:3001/menu"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &PuppeteerMenuProvider{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout, Transport: config.Transport},
+	}
+}
+
+func (p *PuppeteerMenuProvider) FetchMenus(ctx context.Context, codes []string, location structure.Location, market structure.Market) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"code":      codes,
+		"longitude": location.Longitude,
+		"latitude":  location.Latitude,
+		"country":   market.Country,
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.URL, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create menu request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.AuthHeader != "" {
+		req.Header.Set("Authorization", p.config.AuthHeader)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, apierror.FromTransport(menuServiceName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, apierror.FromStatus(menuServiceName, resp.StatusCode, body)
+	}
+
+	var menuResponse struct {
+		Success bool                     `json:"success"`
+		Results []map[string]interface{} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&menuResponse); err != nil {
+		return nil, apierror.Malformed(menuServiceName, err)
+	}
+
+	if !menuResponse.Success {
+		return nil, apierror.New(apierror.KindUnavailable, menuServiceName, fmt.Errorf("menu request failed"))
+	}
+
+	menuMap := make(map[string]interface{})
+	for _, result := range menuResponse.Results {
+		code, ok := result["code"].(string)
+		if !ok {
+			return nil, apierror.Malformed(menuServiceName, fmt.Errorf("invalid code type"))
+		}
+		menuMap[code] = result // Keep as raw interface{}
+	}
+
+	return menuMap, nil
+}
+
+// NativeMenuProvider fetches menus directly from Foodpanda with the Go client
+type NativeMenuProvider struct {
+	client      *foodpanda.Client
+	concurrency int
+}
+
+// NewNativeMenuProvider creates a provider fetching up to concurrency menus at once
+func NewNativeMenuProvider(client *foodpanda.Client, concurrency int) *NativeMenuProvider {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	return &NativeMenuProvider{client: client, concurrency: concurrency}
+}
+
+func (p *NativeMenuProvider) FetchMenus(ctx context.Context, codes []string, location structure.Location, market structure.Market) (map[string]interface{}, error) {
+	results := make([]map[string]interface{}, len(codes))
+	sem := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+
+	for i, code := range codes {
+		wg.Add(1)
+		go func(i int, code string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			menuResp, err := p.client.VendorMenu(ctx, code, foodpanda.MenuOptions{
+				Latitude:   location.Latitude,
+				Longitude:  location.Longitude,
+				Country:    market.Country,
+				LanguageID: market.LanguageID,
+			})
+			if err != nil {
+				results[i] = map[string]interface{}{"code": code, "error": err.Error()}
+				return
+			}
+			results[i] = summarizeMenu(code, menuResp)
+		}(i, code)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	menuMap := make(map[string]interface{}, len(codes))
+	for i, code := range codes {
+		menuMap[code] = results[i]
+	}
+
+	return menuMap, nil
+}
+
+// summarizeMenu converts a Foodpanda menu into the puppeteer service's format
+func summarizeMenu(code string, menuResp *structure.FoodPandaMenuResponse) map[string]interface{} {
+	var menus []interface{}
+	for _, menu := range menuResp.Data.Menus {
+		var categories []interface{}
+		for _, category := range menu.MenuCategories {
+			var items []interface{}
+			for _, product := range category.Products {
+				var price float64
+				if len(product.ProductVariations) > 0 {
+					price = product.ProductVariations[0].Price
+				}
+				items = append(items, map[string]interface{}{
+					"id":          product.ID,
+					"name":        product.Name,
+					"description": product.Description,
+					"price":       price,
+				})
+			}
+			categories = append(categories, map[string]interface{}{
+				"id":          category.ID,
+				"name":        category.Name,
+				"description": category.Description,
+				"menu_items":  items,
+			})
+		}
+		menus = append(menus, map[string]interface{}{
+			"id":              menu.ID,
+			"menu_categories": categories,
+		})
+	}
+
+	return map[string]interface{}{
+		"name":     menuResp.Data.Name,
+		"code":     code,
+		"web_path": menuResp.Data.WebPath,
+		"menus":    menus,
+	}
+}
+
+// FixtureMenuProvider serves canned menus, for development and tests
+type FixtureMenuProvider struct {
+	menus map[string]map[string]interface{}
+}
+
+// NewFixtureMenuProvider creates a provider serving the given menus by code
+func NewFixtureMenuProvider(menus map[string]map[string]interface{}) *FixtureMenuProvider {
+	return &FixtureMenuProvider{menus: menus}
+}
+
+// LoadFixtureMenuProvider reads one <code>.json file per vendor from dir
+func LoadFixtureMenuProvider(dir string) (*FixtureMenuProvider, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	menus := make(map[string]map[string]interface{})
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read menu fixture: %w", err)
+		}
+
+		var menu map[string]interface{}
+		if err := json.Unmarshal(raw, &menu); err != nil {
+			return nil, fmt.Errorf("failed to parse menu fixture %s: %w", file, err)
+		}
+
+		code := filepath.Base(file)
+		code = code[:len(code)-len(".json")]
+		menus[code] = menu
+	}
+
+	return NewFixtureMenuProvider(menus), nil
+}
+
+func (p *FixtureMenuProvider) FetchMenus(ctx context.Context, codes []string, location structure.Location, market structure.Market) (map[string]interface{}, error) {
+	menuMap := make(map[string]interface{}, len(codes))
+	for _, code := range codes {
+		if menu, ok := p.menus[code]; ok {
+			menuMap[code] = menu
+		} else {
+			menuMap[code] = map[string]interface{}{"code": code, "error": errNoFixture.Error()}
+		}
+	}
+	return menuMap, nil
+}
+
+var errNoFixture = errors.New("no menu fixture for vendor")
@@ -0,0 +1,139 @@
+package vertex
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/experiment"
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/menustore"
+	"what-to-eat/pkg/replay"
+)
+
+// The fixtures in testdata/replay hold the six-vendor listing around Taipei
+// 101 used by the picker tests, the puppeteer service's menus for its five
+// delivering vendors and the model's answers to the requests below
+const (
+	spicyHotPot     = `{"userPreference": "something spicy and soupy", "location": {"latitude": 25.033964, "longitude": 121.564468}, "availableCategories": [{"id": 1203, "label": "拉麵"}, {"id": 1214, "label": "火鍋"}, {"id": 1215, "label": "便當"}]}`
+	spicySuggestion = `{"initial_preference": "something spicy and soupy", "additional_detail": "the spicier the better", "location": {"latitude": 25.033964, "longitude": 121.564468}}`
+)
+
+// useReplayUpstreams answers Foodpanda, menu service and Vertex AI calls
+// from testdata/replay, with an empty menu store
+func useReplayUpstreams(t *testing.T) {
+	t.Helper()
+	useReplayVertex(t, "testdata/replay")
+
+	transport, err := replay.New(replay.ModeReplay, "testdata/replay", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := foodpanda.DefaultConfig()
+	config.Transport = transport
+	config.MaxRetries = 0
+
+	previousClient, previousMenus, previousStore := foodpanda.DefaultClient, Menus, menustore.Default
+	foodpanda.DefaultClient = foodpanda.NewClient(config)
+	Menus = NewPuppeteerMenuProvider(PuppeteerConfig{Transport: transport})
+	menustore.Default = menustore.NewMemoryStore(0)
+	t.Cleanup(func() {
+		foodpanda.DefaultClient, Menus, menustore.Default = previousClient, previousMenus, previousStore
+	})
+}
+
+func suggest(t *testing.T) (*httptest.ResponseRecorder, MenuFetchRestaurantInfo) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	RestaurantSuggestion(w, httptest.NewRequest("POST", "/suggestion?clientId=abc", strings.NewReader(spicySuggestion)))
+
+	var resp MenuFetchRestaurantInfo
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return w, resp
+}
+
+func TestFilteredCategoriesReplay(t *testing.T) {
+	useReplayVertex(t, "testdata/replay")
+
+	w := httptest.NewRecorder()
+	FilteredCategories(w, httptest.NewRequest("POST", "/filter?mode=llm", strings.NewReader(spicyHotPot)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("X-Matcher"); got != FilterModeLLM {
+		t.Errorf("X-Matcher = %q, want %q", got, FilterModeLLM)
+	}
+
+	var categories []struct {
+		ID    int    `json:"id"`
+		Label string `json:"label"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&categories); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(categories) != 1 || categories[0].ID != 1214 {
+		t.Errorf("categories = %+v, want hot pot (1214)", categories)
+	}
+}
+
+func TestRestaurantSuggestionReplay(t *testing.T) {
+	useReplayUpstreams(t)
+
+	w, resp := suggest(t)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if resp.Code != "h3pt" || resp.Name != "Hot Pot House" || resp.Market.Country != "tw" {
+		t.Errorf("suggested %+v, want Hot Pot House in tw", resp)
+	}
+	if got := w.Header().Get("X-Cache"); got != foodpanda.CacheMiss {
+		t.Errorf("X-Cache = %q, want %q", got, foodpanda.CacheMiss)
+	}
+
+	// Menus are kept in the menu store, so a repeat needs no menu service
+	Menus = NewPuppeteerMenuProvider(PuppeteerConfig{URL: "http://localhost:1/menu"})
+	if w, resp := suggest(t); w.Code != http.StatusOK || resp.Code != "h3pt" {
+		t.Errorf("repeat: status = %d, code = %q; want the stored menus to be reused", w.Code, resp.Code)
+	}
+}
+
+func TestRestaurantSuggestionReplayUsesVariantModel(t *testing.T) {
+	useReplayUpstreams(t)
+	registry, err := experiment.NewRegistry([]experiment.Experiment{{
+		Name:    "suggestion-model",
+		Surface: experiment.SurfaceSuggestion,
+		Variants: []experiment.Variant{
+			{Name: "a", Model: "gemini-unrecorded"},
+			{Name: "b", Model: "gemini-unrecorded"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := experiment.NewMemoryStore(0)
+	previousRegistry, previousStore := experiment.Default, experiment.DefaultStore
+	experiment.Default, experiment.DefaultStore = registry, store
+	t.Cleanup(func() { experiment.Default, experiment.DefaultStore = previousRegistry, previousStore })
+
+	// Only the default model's answer was recorded, so the variant's call
+	// misses and the failure still counts as an exposure
+	w, _ := suggest(t)
+	var body apierror.Response
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusServiceUnavailable || body.Error.Code != string(apierror.KindUnavailable) {
+		t.Errorf("status = %d %s, want 503 %s", w.Code, body.Error.Code, apierror.KindUnavailable)
+	}
+	if got := w.Header().Get("X-Experiment"); !strings.HasPrefix(got, "suggestion-model/") {
+		t.Errorf("X-Experiment = %q, want the suggestion-model assignment", got)
+	}
+	if outcomes, _ := store.Outcomes("suggestion-model"); len(outcomes) != 1 || outcomes[0].Event != experiment.EventExposure {
+		t.Errorf("outcomes = %+v, want one exposure", outcomes)
+	}
+}
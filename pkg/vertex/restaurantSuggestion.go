@@ -1,18 +1,25 @@
 package vertex
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
-	"strings"
-	"time"
+
+	"what-to-eat/pkg/apierror"
+	"what-to-eat/pkg/cuisine"
+	"what-to-eat/pkg/experiment"
+	"what-to-eat/pkg/foodpanda"
+	"what-to-eat/pkg/menustore"
 	"what-to-eat/pkg/structure"
 )
 
 type RestaurantSuggestionRequestBody struct {
 	InitialPreference string `json:"initial_preference"`
 	AdditionalDetail  string `json:"additional_detail"`
+	Country           string `json:"country"`
+	Language          string `json:"language"`
 	Location          struct {
 		Latitude  float64 `json:"latitude"`
 		Longitude float64 `json:"longitude"`
@@ -24,13 +31,14 @@ type RestaurantSuggestionRequestBody struct {
 }
 
 type MenuFetchRestaurantInfo struct {
-	Id             string `json:"id"`
-	Heroimage      string `json:"hero_image"`
-	Name           string `json:"name"`
-	RedirectionURL string `json:"redirection_url"`
-	Code           string `json:"code"`
-	Longitude      string `json:"longitude"`
-	Latitude       string `json:"latitude"`
+	Id             string           `json:"id"`
+	Heroimage      string           `json:"hero_image"`
+	Name           string           `json:"name"`
+	RedirectionURL string           `json:"redirection_url"`
+	Code           string           `json:"code"`
+	Longitude      string           `json:"longitude"`
+	Latitude       string           `json:"latitude"`
+	Market         structure.Market `json:"market"`
 }
 
 type GeminiSuggestionRequestBody struct {
@@ -44,7 +52,7 @@ type GeminiSuggestionRequestBody struct {
 		ID    int    `json:"id"`
 		Label string `json:"label"`
 	} `json:"cuisines"`
-	Menus map[string]interface{}
+	Menus map[string]interface{} `json:"menus"`
 }
 
 type GeminiSuggestionRespond struct {
@@ -59,51 +67,23 @@ func init() {
 	InitProjectInfo()
 }
 
-// fetchNearRestaurant fetches nearby restaurants from the Foodpanda API.
-func fetchNearRestaurant(latitude float64, longitude float64, cuisineIDs []string) ([]MenuFetchRestaurantInfo, error) {
-	// Prepare the Foodpanda API request
-	client := &http.Client{
-		Timeout: 25 * time.Second, // Add timeout for safety
-	}
-	foodpandaURL := "https://disco.deliveryhero.io/listing/api/v1/pandora/vendors"
-	req, err := http.NewRequest("GET", foodpandaURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Foodpanda API request: %w", err)
-	}
-
-	// Set query parameters
-	q := req.URL.Query()
-	q.Add("country", "tw")
-	q.Add("latitude", fmt.Sprintf("%f", latitude))
-	q.Add("longitude", fmt.Sprintf("%f", longitude))
-	q.Add("language_id", "6")
-	q.Add("include", "characteristics")
-	q.Add("dynamic_pricing", "0")
-	q.Add("configuration", "Original")
-	q.Add("vertical", "restaurants")
-	q.Add("limit", "999")
-	q.Add("offset", "0")
-	q.Add("customer_type", "regular")
-
-	if len(cuisineIDs) > 0 {
-		q.Add("cuisine", strings.Join(cuisineIDs, ","))
-	}
-	req.URL.RawQuery = q.Encode()
-	req.Header.Add("x-disco-client-id", "web")
+// maxSuggestionVendors caps how many vendors, in listing order, have their
+// menus fetched for one suggestion
+const maxSuggestionVendors = 30
 
-	// Make the HTTP request
-	resp, err := client.Do(req)
+// fetchNearRestaurant fetches nearby restaurants from the Foodpanda API.
+func fetchNearRestaurant(ctx context.Context, latitude float64, longitude float64, cuisineIDs []string, market structure.Market) ([]MenuFetchRestaurantInfo, string, error) {
+	// Fetch nearby vendors from Foodpanda
+	foodpandaResp, cacheStatus, err := foodpanda.DefaultClient.CachedListAllVendors(ctx, foodpanda.ListingOptions{
+		Latitude:   latitude,
+		Longitude:  longitude,
+		Cuisines:   cuisineIDs,
+		Country:    market.Country,
+		LanguageID: market.LanguageID,
+	}, 0)
 	if err != nil {
-		return nil, fmt.Errorf("error making Foodpanda API request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Parse the Foodpanda API response
-	var foodpandaResp structure.FoodPandaRestaurantResponse
-	if err := json.NewDecoder(resp.Body).Decode(&foodpandaResp); err != nil {
-		return nil, fmt.Errorf("error decoding Foodpanda API response: %w", err)
+		return nil, cacheStatus, fmt.Errorf("error fetching Foodpanda vendors: %w", err)
 	}
-	// fmt.Println("Foodpanda resp", foodpandaResp.Data.Items)
 
 	// Transform response into MenuFetchRestaurantInfo format
 	var restaurantInfos []MenuFetchRestaurantInfo
@@ -117,207 +97,85 @@ func fetchNearRestaurant(latitude float64, longitude float64, cuisineIDs []strin
 				Code:           item.Code,
 				Longitude:      fmt.Sprintf("%f", longitude),
 				Latitude:       fmt.Sprintf("%f", latitude),
+				Market:         market,
 			}
 			restaurantInfos = append(restaurantInfos, info)
 		}
 	}
-	// fmt.Println("Restaurant info", restaurantInfos)
 
-	return restaurantInfos, nil
+	return restaurantInfos, cacheStatus, nil
 }
 
-func fetchRestaurantMenu(restaurantCodes []string, latitude, longitude float64) (map[string]interface{}, error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+// fetchRestaurantMenu returns menus for the given vendors, serving what it
+// can from the menu store and fetching the rest from the configured provider
+func fetchRestaurantMenu(ctx context.Context, restaurantCodes []string, location structure.Location, market structure.Market) (map[string]interface{}, error) {
+	menuMap := make(map[string]interface{})
 
-	payload := map[string]interface{}{
-		"code":      restaurantCodes,
-		"longitude": longitude,
-		"latitude":  latitude,
+	// Serve what we can from the menu store
+	var missingCodes []string
+	for _, code := range restaurantCodes {
+		var menu map[string]interface{}
+		found, err := menustore.GetJSON(menustore.Default, menustore.Key("summary", market.Country, market.Language, code), &menu)
+		if err != nil {
+			log.Printf("Failed to read menu store: %v", err)
+		}
+		if found {
+			menuMap[code] = menu
+		} else {
+			missingCodes = append(missingCodes, code)
+		}
 	}
-
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	if len(missingCodes) == 0 {
+		return menuMap, nil
 	}
 
-	req, err := http.NewRequest("POST", "http://localhost:3001/menu", strings.NewReader(string(payloadBytes)))
+	fetched, err := Menus.FetchMenus(ctx, missingCodes, location, market)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create menu request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	for code, result := range fetched {
+		menuMap[code] = result
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch restaurant menus: %w", err)
+		// Failed fetches come back with an error field and are not stored
+		if menu, ok := result.(map[string]interface{}); ok {
+			if _, failed := menu["error"]; !failed {
+				if err := menustore.SetJSON(menustore.Default, menustore.Key("summary", market.Country, market.Language, code), menu, menustore.DefaultTTL); err != nil {
+					log.Printf("Failed to write menu store: %v", err)
+				}
+			}
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("menu request returned non-OK status: %d, body: %s", resp.StatusCode, string(body))
-	}
+	return menuMap, nil
+}
 
-	var menuResponse struct {
-		Success bool                     `json:"success"`
-		Results []map[string]interface{} `json:"results"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&menuResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode menu response: %w", err)
+// aiSuggestion asks the model which of the fetched restaurants best fits
+// the user's preference
+func aiSuggestion(ctx context.Context, requestBody RestaurantSuggestionRequestBody, menus map[string]interface{}, systemPrompt, modelID string) (GeminiSuggestionRespond, error) {
+	// Combine request body and menus into a single struct
+	aiRequestBody := GeminiSuggestionRequestBody{
+		InitialPreference: requestBody.InitialPreference,
+		AdditionalDetail:  requestBody.AdditionalDetail,
+		Location:          requestBody.Location,
+		Cuisines:          requestBody.Cuisines,
+		Menus:             menus,
 	}
 
-	if !menuResponse.Success {
-		return nil, fmt.Errorf("menu request failed")
+	// Convert the struct to JSON
+	aiInput, err := json.Marshal(aiRequestBody)
+	if err != nil {
+		return GeminiSuggestionRespond{}, fmt.Errorf("failed to marshal AI request body: %w", err)
 	}
 
-	menuMap := make(map[string]interface{})
-	for _, result := range menuResponse.Results {
-		code, ok := result["code"].(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid code type")
-		}
-		menuMap[code] = result // Keep as raw interface{}
+	parsedResponse, err := generateContent(ctx, aiInput, systemPrompt, modelID)
+	if err != nil {
+		return GeminiSuggestionRespond{}, err
 	}
 
-	return menuMap, nil
-}
-
-func aiSuggestion(requestBody RestaurantSuggestionRequestBody, menus map[string]interface{}) (GeminiSuggestionRespond, error) {
-	// // Combine request body and menus into a single struct
-	// aiRequestBody := GeminiSuggestionRequestBody{
-	// 	InitialPreference: requestBody.InitialPreference,
-	// 	AdditionalDetail:  requestBody.AdditionalDetail,
-	// 	Location:          requestBody.Location,
-	// 	Cuisines:          requestBody.Cuisines,
-	// 	Menus:             menus,
-	// }
-
-	// // Convert the struct to JSON
-	// aiRequestBodyJSON, err := json.Marshal(aiRequestBody)
-	// if err != nil {
-	// 	return GeminiSuggestionRespond{}, fmt.Errorf("failed to marshal AI request body: %w", err)
-	// }
-
-	// // Create AI request payload
-	// requestPayload := structure.VertexAIRequest{
-	// 	Contents: []struct {
-	// 		Role  string `json:"role"`
-	// 		Parts []struct {
-	// 			Text string `json:"text"`
-	// 		} `json:"parts"`
-	// 	}{
-	// 		{
-	// 			Role: "user",
-	// 			Parts: []struct {
-	// 				Text string `json:"text"`
-	// 			}{
-	// 				{
-	// 					Text: string(aiRequestBodyJSON),
-	// 				},
-	// 			},
-	// 		},
-	// 	},
-
-	// 	SystemInstruction: struct {
-	// 		Parts []struct {
-	// 			Text string `json:"text"`
-	// 		} `json:"parts"`
-	// 	}{
-	// 		Parts: []struct {
-	// 			Text string `json:"text"`
-	// 		}{
-	// 			{
-	// 				Text: "You are a restaurant picker bot. You will receive user preferences and local restaurant data in JSON format. Your task is to analyze this data and determine the restaurant that best fits the user's needs.\n\nYou will receive input in the following JSON structure:\n\n```json\n{\n    \"initial_preference\": \"user's initial preference\",\n    \"additional_Details\": \"additional details about user's preference\",\n    \"location\": {\n        \"latitude\": \"latitude of user's location\",\n        \"longitude\": \"longitude of user's location\"\n    },\n    \"cuisines\": [\n        {\n            \"id\": \"cuisine ID\",\n            \"label\": \"cuisine label\"\n        },\n        // ... more cuisines\n    ],\n    \"menus\": {\n        \"restaurant_code_1\": {\n            \"code\": \"restaurant code\",\n            \"name\": \"restaurant name\",\n            \"web_path\": \"restaurant web path\",\n            \"menus\": [\n                {\n                    \"id\": \"menu ID\",\n                    \"menu_categories\": [\n                        {\n                            \"description\": \"category description\",\n                            \"id\": \"category ID\",\n                            \"name\": \"category name\",\n                            \"menu_items\": [\n                                {\n                                    \"description\": \"item description\",\n                                    \"id\": \"item ID\",\n                                    \"name\": \"item name\",\n                                    \"price\": \"item price\"\n                                },\n                                // ... more menu items\n                            ]\n                        },\n                        // ... more menu categories\n                    ]\n                },\n                // ... more menus\n            ]\n        },\n        // ... more restaurants\n    }\n}\n```\n\nBased on this information, determine the restaurant that best suits the user's preferences, considering their initial preference, additional details, location, preferred cuisines, and the available menu items.  Pay close attention to the user's desired spice level.\n\nGenerate a JSON response in the following format:\n\n```json\n{\n\t\"code\":\"restaurant_code\",\n\t\"reason\":\"your reason for choosing this restaurant\"\n}\n```\n\nEnsure the `reason` field clearly and concisely explains why the chosen restaurant is the best match for the user.  Consider all available information when making your decision.",
-	// 			},
-	// 		},
-	// 	},
-	// 	GenerationConfig: struct {
-	// 		Temperature     float64 `json:"temperature"`
-	// 		MaxOutputTokens int     `json:"maxOutputTokens"`
-	// 		TopP            float64 `json:"topP"`
-	// 		Seed            int     `json:"seed"`
-	// 	}{
-	// 		Temperature:     0.1,
-	// 		MaxOutputTokens: 8192,
-	// 		TopP:            0.95,
-	// 		Seed:            0,
-	// 	},
-	// 	SafetySettings: []struct {
-	// 		Category  string `json:"category"`
-	// 		Threshold string `json:"threshold"`
-	// 	}{
-	// 		{
-	// 			Category:  "HARM_CATEGORY_HATE_SPEECH",
-	// 			Threshold: "OFF",
-	// 		},
-	// 	},
-	// }
-
-	// payloadBytes, err := json.Marshal(requestPayload)
-	// if err != nil {
-	// 	return GeminiSuggestionRespond{}, fmt.Errorf("failed to marshal payload: %w", err)
-	// }
-
-	// // Send the request to Vertex AI
-	// apiURL := fmt.Sprintf(
-	// 	"https://%s/v1/projects/%s/locations/%s/publishers/google/models/%s:streamGenerateContent",
-	// 	ProjectInfo.ApiEndpoint, ProjectInfo.ProjectID, ProjectInfo.Location, ProjectInfo.ModelID,
-	// )
-	// req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(payloadBytes))
-	// if err != nil {
-	// 	return GeminiSuggestionRespond{}, fmt.Errorf("failed to create request: %w", err)
-	// }
-
-	// // Get google oauth token
-	// accessToken, err := OauthGoogle()
-	// if err != nil {
-	// 	return GeminiSuggestionRespond{}, fmt.Errorf("failed to get access token: %w", err)
-	// }
-
-	// // Set headers
-	// req.Header.Set("Content-Type", "application/json")
-	// req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-
-	// // Execute the request
-	// client := &http.Client{}
-	// resp, err := client.Do(req)
-	// if err != nil {
-	// 	return GeminiSuggestionRespond{}, fmt.Errorf("failed to send request: %w", err)
-	// }
-	// defer resp.Body.Close()
-
-	// // Read the response
-	// body, err := io.ReadAll(resp.Body)
-	// if err != nil {
-	// 	return GeminiSuggestionRespond{}, fmt.Errorf("failed to read response body: %w", err)
-	// }
-
-	// // Parse the response
-	// parsedResponse, err := ParseVertexAIResponse(string(body))
-	// // fmt.Println(parsedResponse)
-
-	// // Create an instance of the struct
-	// var aiResponse GeminiSuggestionRespond
-	// unmarshalErr := json.Unmarshal([]byte(parsedResponse), &aiResponse)
-	// if unmarshalErr != nil {
-	// 	return GeminiSuggestionRespond{}, fmt.Errorf("failed to unmarshal AI response: %w", err)
-	// }
-
-	// DEBUG
-	var firstCode string
-	for _, value := range menus {
-		menuMap := value.(map[string]interface{})
-		if code, ok := menuMap["code"].(string); ok {
-			firstCode = code
-			fmt.Println("First Code Found:", firstCode)
-			break
-		}
-	}
-	aiResponse := GeminiSuggestionRespond{
-		Code:   firstCode,
-		Reason: "test",
+	var aiResponse GeminiSuggestionRespond
+	if err := json.Unmarshal([]byte(parsedResponse), &aiResponse); err != nil {
+		return GeminiSuggestionRespond{}, apierror.Malformed(upstreamName, fmt.Errorf("failed to unmarshal AI response: %w", err))
 	}
 
 	return aiResponse, nil
@@ -327,8 +185,7 @@ func RestaurantSuggestion(w http.ResponseWriter, r *http.Request) {
 	// Parse the request body
 	var requestBody RestaurantSuggestionRequestBody
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		fmt.Println("Error decoding request body:", err)
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body: "+err.Error())
 		return
 	}
 
@@ -336,6 +193,19 @@ func RestaurantSuggestion(w http.ResponseWriter, r *http.Request) {
 	latitude := requestBody.Location.Latitude
 	longitude := requestBody.Location.Longitude
 
+	// Resolve the market from explicit fields or the coordinates
+	market, err := foodpanda.ResolveMarket(requestBody.Country, requestBody.Language, latitude, longitude)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid market: "+err.Error())
+		return
+	}
+
+	// Label cuisines in the negotiated language before they reach the AI
+	language := cuisine.Negotiate(r, market.Language)
+	for i, c := range requestBody.Cuisines {
+		requestBody.Cuisines[i].Label = cuisine.Label(c.ID, language, c.Label)
+	}
+
 	// Extract cuisine IDs
 	var cuisineIDs []string
 	for _, cuisine := range requestBody.Cuisines {
@@ -343,13 +213,22 @@ func RestaurantSuggestion(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch nearby restaurants
-	restaurantInfos, err := fetchNearRestaurant(latitude, longitude, cuisineIDs)
+	restaurantInfos, cacheStatus, err := fetchNearRestaurant(r.Context(), latitude, longitude, cuisineIDs, market)
 	if err != nil {
-		fmt.Println("Error fetching restaurants:", err)
-		http.Error(w, "Failed to fetch restaurants: "+err.Error(), http.StatusInternalServerError)
+		apierror.WriteError(w, r, "Failed to fetch restaurants", err)
+		return
+	}
+
+	if len(restaurantInfos) == 0 {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "No available restaurants found")
 		return
 	}
 
+	// Only the most relevant vendors are worth fetching menus for
+	if len(restaurantInfos) > maxSuggestionVendors {
+		restaurantInfos = restaurantInfos[:maxSuggestionVendors]
+	}
+
 	// Collect restaurant IDs
 	var restaurantCodes []string
 	for _, info := range restaurantInfos {
@@ -357,19 +236,31 @@ func RestaurantSuggestion(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch menus for all restaurants
-	menus, err := fetchRestaurantMenu(restaurantCodes, latitude, longitude)
+	menus, err := fetchRestaurantMenu(r.Context(), restaurantCodes, structure.Location{Latitude: latitude, Longitude: longitude}, market)
 	if err != nil {
-		fmt.Println("Error fetching menus:", err)
-		http.Error(w, "Failed to fetch menus: "+err.Error(), http.StatusInternalServerError)
+		apierror.WriteError(w, r, "Failed to fetch menus", err)
 		return
 	}
-	// fmt.Println("Menus:", menus)
+
+	// Clients may be enrolled in a prompt or model experiment
+	systemPrompt := suggestionPrompts[defaultSuggestionPrompt]
+	modelID := ProjectInfo.ModelID
+	clientID := experiment.ClientID(r)
+	if assignment, ok := experiment.Default.Assign(experiment.SurfaceSuggestion, clientID); ok {
+		if prompt, ok := suggestionPrompts[assignment.Variant.PromptVersion]; ok {
+			systemPrompt = prompt
+		}
+		if assignment.Variant.Model != "" {
+			modelID = assignment.Variant.Model
+		}
+		// Expose before the call so failures count against the variant
+		experiment.Expose(w, assignment, clientID, "")
+	}
 
 	// Send menus and user preference to AI
-	suggestion, err := aiSuggestion(requestBody, menus)
+	suggestion, err := aiSuggestion(r.Context(), requestBody, menus, systemPrompt, modelID)
 	if err != nil {
-		fmt.Println("Error getting AI suggestion:", err)
-		http.Error(w, "Failed to get AI suggestion: "+err.Error(), http.StatusInternalServerError)
+		apierror.WriteError(w, r, "Failed to get AI suggestion", err)
 		return
 	}
 
@@ -385,18 +276,15 @@ func RestaurantSuggestion(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if found {
-		fmt.Printf("Matched Restaurant: %+v\n", matchedRestaurant)
-	} else {
-		fmt.Printf("No restaurant matches the code: %s\n", suggestion.Code)
-		// fmt.Println(restaurantInfos)
+	if !found {
+		apierror.WriteError(w, r, "Failed to get AI suggestion", apierror.Malformed(upstreamName, fmt.Errorf("model suggested unknown restaurant: %s", suggestion.Code)))
+		return
 	}
 
 	// Return the final response as JSON
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache", cacheStatus)
 	if err := json.NewEncoder(w).Encode(matchedRestaurant); err != nil {
-		fmt.Println("Error encoding response:", err)
-		http.Error(w, "Failed to encode response: "+err.Error(), http.StatusInternalServerError)
-		return
+		log.Printf("Failed to encode suggestion response: %v", err)
 	}
 }
@@ -180,7 +180,7 @@ class FoodDeliveryAPI {
     this.browser = null;
   }
 
-  private async createPage(): Promise<Page> {
+  private async createPage(country: string): Promise<Page> {
     if (!this.browser) {
       throw new Error("Browser not initialized");
     }
@@ -194,7 +194,7 @@ class FoodDeliveryAPI {
     await page.setUserAgent(this.userAgents[0]);
     
     // Set cookies
-    const domain = 'tw.fd-api.com';
+    const domain = `${country}.fd-api.com`;
     await page.setCookie(...this.cookies.map(cookie => ({
       ...cookie,
       domain,
@@ -284,7 +284,8 @@ class FoodDeliveryAPI {
   async getVendorMenu(
     code: string,
     longitude: number,
-    latitude: number
+    latitude: number,
+    country = "tw"
   ): Promise<MenuRespond> {
     // Try to get from cache first
     const cachedMenu = await this.menuCache.getMenu(code);
@@ -294,10 +295,10 @@ class FoodDeliveryAPI {
     }
 
     // If not in cache, fetch from API
-    const page = await this.createPage();
+    const page = await this.createPage(country);
 
     try {
-      const url = `https://tw.fd-api.com/api/v5/vendors/${code}?include=menus&longitude=${longitude}&latitude=${latitude}`;
+      const url = `https://${country}.fd-api.com/api/v5/vendors/${code}?include=menus&longitude=${longitude}&latitude=${latitude}`;
       const jsonData = (await this.fetchWithRetry(page, url)) as { data: any };
 
       if (!jsonData?.data) {
@@ -361,13 +362,21 @@ app.listen(3001, async () => {
 });
 
 app.post("/menu", async (req: Request, res: Response): Promise<void> => {
-  const { code: codes, longitude, latitude } = req.body;
+  const authToken = process.env.MENU_AUTH_TOKEN;
+  if (authToken && req.get("authorization") !== authToken) {
+    res.status(401).json({ error: "Unauthorized" });
+    return;
+  }
+
+  const { code: codes, longitude, latitude, country = "tw" } = req.body;
 
   if (
     !Array.isArray(codes) ||
     !codes.length ||
     typeof longitude !== "number" ||
-    typeof latitude !== "number"
+    typeof latitude !== "number" ||
+    typeof country !== "string" ||
+    !/^[a-z]{2}$/.test(country)
   ) {
     res.status(400).json({ error: "Invalid request parameters" });
     return;
@@ -377,7 +386,7 @@ app.post("/menu", async (req: Request, res: Response): Promise<void> => {
     const results = await Promise.all(
       codes.map(async (code) => {
         try {
-          return await foodDeliveryAPI.getVendorMenu(code, longitude, latitude);
+          return await foodDeliveryAPI.getVendorMenu(code, longitude, latitude, country);
         } catch (error) {
           return {
             code,